@@ -0,0 +1,34 @@
+package uuid
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+	if !Valid(a) || a[14] != '4' {
+		t.Errorf("New() = %q, want a version 4 UUID", a)
+	}
+	if a == b {
+		t.Errorf("New() returned %q twice", a)
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"8f1c6c2e-4d7a-4b3e-9a51-0c2d8e7f6a90", true},
+		{"8F1C6C2E-4D7A-4B3E-9A51-0C2D8E7F6A90", true},
+		{"", false},
+		{"8f1c6c2e4d7a4b3e9a510c2d8e7f6a90", false},
+		{"8f1c6c2e-4d7a-4b3e-9a51-0c2d8e7f6a9", false},
+		{"8f1c6c2e-4d7a-4b3e-9a51_0c2d8e7f6a90", false},
+		{"8f1c6c2e-4d7a-4b3e-9a51-0c2d8e7f6a9g", false},
+		{"../../v1/contacts?x=8f1c6c2e-4d7a-4b", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.s); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -33,9 +33,42 @@ import (
 
 // Re-export main client types
 type (
-	Client   = lexware.Client
-	Config   = lexware.Config
-	APIError = lexware.APIError
+	Client               = lexware.Client
+	Config               = lexware.Config
+	APIError             = lexware.APIError
+	RetryPolicy          = lexware.RetryPolicy
+	Issue                = lexware.Issue
+	Request              = lexware.Request
+	Handler              = lexware.Handler
+	Middleware           = lexware.Middleware
+	Metrics              = lexware.Metrics
+	RateLimiter          = lexware.RateLimiter
+	TokenSource          = lexware.TokenSource
+	Cache                = lexware.Cache
+	IdempotencyStore     = lexware.IdempotencyStore
+	IdempotencyRecord    = lexware.IdempotencyRecord
+	CircuitBreaker       = lexware.CircuitBreaker
+	CircuitBreakerConfig = lexware.CircuitBreakerConfig
+	CircuitState         = lexware.CircuitState
+	Priority             = lexware.Priority
+	CallOption           = lexware.CallOption
+	InvalidIDError       = lexware.InvalidIDError
+	Batch                = lexware.Batch
+	BatchOptions         = lexware.BatchOptions
+	BatchError           = lexware.BatchError
+)
+
+// Re-export sentinel errors
+var (
+	ErrNotFound       = lexware.ErrNotFound
+	ErrConflict       = lexware.ErrConflict
+	ErrValidation     = lexware.ErrValidation
+	ErrRateLimited    = lexware.ErrRateLimited
+	ErrUnauthorized   = lexware.ErrUnauthorized
+	ErrOutcomeUnknown = lexware.ErrOutcomeUnknown
+	ErrCircuitOpen    = lexware.ErrCircuitOpen
+	ErrInvalidID      = lexware.ErrInvalidID
+	ErrInvalidSort    = lexware.ErrInvalidSort
 )
 
 // Re-export common types
@@ -93,4 +126,8 @@ func NewClientWithConfig(config Config) (*Client, error) {
 const (
 	DefaultBaseURL = lexware.DefaultBaseURL
 	DefaultTimeout = lexware.DefaultTimeout
+
+	PriorityLow    = lexware.PriorityLow
+	PriorityNormal = lexware.PriorityNormal
+	PriorityHigh   = lexware.PriorityHigh
 )
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -11,19 +12,15 @@ import (
 type articlesClient struct{ client *Client }
 
 func (c *articlesClient) Create(ctx context.Context, article *types.ArticleCreateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "POST", "/v1/articles", article)
-	if err != nil {
-		return nil, err
-	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "Articles.Create"}, "/v1/articles", article)
 }
 
 func (c *articlesClient) Get(ctx context.Context, id string) (*types.Article, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/articles/"+id, nil)
+	path, err := idPath("/v1/articles", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Articles.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +32,11 @@ func (c *articlesClient) Get(ctx context.Context, id string) (*types.Article, er
 }
 
 func (c *articlesClient) Update(ctx context.Context, id string, article *types.ArticleUpdateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "PUT", "/v1/articles/"+id, article)
+	path, err := idPath("/v1/articles", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Articles.Update", resourceID: id}, "PUT", path, article)
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +48,19 @@ func (c *articlesClient) Update(ctx context.Context, id string, article *types.A
 }
 
 func (c *articlesClient) Delete(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "DELETE", "/v1/articles/"+id, nil)
+	path, err := idPath("/v1/articles", id)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "Articles.Delete", resourceID: id}, "DELETE", path, nil)
 	return err
 }
 
 func (c *articlesClient) List(ctx context.Context, opts *types.ListOptions, filter *types.ArticleFilterOptions) (*types.Page[types.Article], error) {
 	params := make(map[string]string)
-	addPagination(params, opts)
+	if err := addPagination(params, opts, "/v1/articles"); err != nil {
+		return nil, err
+	}
 	if filter != nil {
 		if filter.ArticleNumber != "" {
 			params["articleNumber"] = filter.ArticleNumber
@@ -65,7 +72,7 @@ func (c *articlesClient) List(ctx context.Context, opts *types.ListOptions, filt
 			params["type"] = string(filter.Type)
 		}
 	}
-	body, err := c.client.doRequest(ctx, "GET", "/v1/articles"+buildQueryString(params), nil)
+	body, err := c.client.doRequest(ctx, listOperation("Articles.List", opts), "GET", "/v1/articles"+buildQueryString(params), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -75,3 +82,11 @@ func (c *articlesClient) List(ctx context.Context, opts *types.ListOptions, filt
 	}
 	return &page, nil
 }
+
+func (c *articlesClient) All(ctx context.Context, filter *types.ArticleFilterOptions) iter.Seq2[types.Article, error] {
+	return traceAll(c.client, ctx, "Articles.All", func(ctx context.Context) iter.Seq2[types.Article, error] {
+		return ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.Article], error) {
+			return c.List(ctx, opts, filter)
+		}, func(a types.Article) string { return a.ID })
+	})
+}
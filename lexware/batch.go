@@ -0,0 +1,191 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of operations a batch runs at once by default.
+const DefaultBatchConcurrency = 4
+
+// BatchOptions configures a batch.
+type BatchOptions struct {
+	// Concurrency limits the operations running at once. Defaults to DefaultBatchConcurrency.
+	// Requests of all operations still wait for the rate limiter of their client, so a higher
+	// concurrency only helps if the rate limit isn't exhausted yet.
+	Concurrency int
+	// FailFast cancels the batch after the first failed operation. Operations that haven't started
+	// yet are skipped and fail with the context's error. By default, all operations are run.
+	FailFast bool
+}
+
+// Batch runs operations concurrently with bounded concurrency, like errgroup.Group. Create one
+// with NewBatch, start operations with Go and collect their errors with Wait. A batch isn't tied
+// to a client, so operations may use several clients or the fakes of the lexware/fake package.
+type Batch struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	sem      chan struct{}
+	failFast bool
+	wg       sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewBatch returns a new batch of operations derived from ctx. Cancelling ctx cancels the batch.
+//
+//	batch := lexware.NewBatch(ctx, lexware.BatchOptions{Concurrency: 8})
+//	for i, id := range ids {
+//	    batch.Go(func(ctx context.Context) error {
+//	        invoice, err := client.Invoices().Get(ctx, id)
+//	        invoices[i] = invoice
+//	        return err
+//	    })
+//	}
+//	err := batch.Wait()
+func NewBatch(ctx context.Context, opts BatchOptions) *Batch {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Batch{
+		ctx:      ctx,
+		cancel:   cancel,
+		sem:      make(chan struct{}, concurrency),
+		failFast: opts.FailFast,
+	}
+}
+
+// Go runs fn in a new goroutine with the context of the batch. It blocks while the maximum
+// number of operations is running. The operations are numbered in the order Go is called in,
+// which is the order of the errors reported by Wait.
+func (b *Batch) Go(fn func(ctx context.Context) error) {
+	b.mu.Lock()
+	i := len(b.errs)
+	b.errs = append(b.errs, nil)
+	b.mu.Unlock()
+
+	select {
+	case b.sem <- struct{}{}:
+	case <-b.ctx.Done():
+		b.setErr(i, b.ctx.Err())
+		return
+	}
+	if err := b.ctx.Err(); err != nil {
+		// Cancelled while a slot was free.
+		<-b.sem
+		b.setErr(i, err)
+		return
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer func() { <-b.sem }()
+		if err := fn(b.ctx); err != nil {
+			b.setErr(i, err)
+			if b.failFast {
+				b.cancel()
+			}
+		}
+	}()
+}
+
+func (b *Batch) setErr(i int, err error) {
+	b.mu.Lock()
+	b.errs[i] = err
+	b.mu.Unlock()
+}
+
+// Wait blocks until all started operations have returned. It returns nil if all operations
+// succeeded and a *BatchError otherwise. The batch must not be used after Wait.
+func (b *Batch) Wait() error {
+	b.wg.Wait()
+	b.cancel()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	failed := 0
+	for _, err := range b.errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return &BatchError{Errors: b.errs, Failed: failed}
+}
+
+// BatchError reports the failed operations of a batch. errors.Is and errors.As match the errors
+// of all failed operations.
+type BatchError struct {
+	// Errors holds the error of every operation in the order they were started, nil for those
+	// that succeeded.
+	Errors []error
+	// Failed is the number of failed operations.
+	Failed int
+}
+
+func (e *BatchError) Error() string {
+	first := e.firstErr()
+	if e.Failed == 1 {
+		return fmt.Sprintf("lexware: 1 of %d batch operations failed: %v", len(e.Errors), first)
+	}
+	return fmt.Sprintf("lexware: %d of %d batch operations failed, first error: %v", e.Failed, len(e.Errors), first)
+}
+
+func (e *BatchError) firstErr() error {
+	// Report the cause rather than the cancellation it caused in fail-fast batches.
+	var first error
+	for _, err := range e.Errors {
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Unwrap returns the errors of the failed operations.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, e.Failed)
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// BatchMap calls fn for all items in a batch and returns the results in the order of items. The
+// result of a failed item is the zero value; the error is a *BatchError.
+//
+//	invoices, err := lexware.BatchMap(ctx, ids, lexware.BatchOptions{}, client.Invoices().Get)
+func BatchMap[T, R any](ctx context.Context, items []T, opts BatchOptions, fn func(ctx context.Context, item T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	batch := NewBatch(ctx, opts)
+	for i, item := range items {
+		batch.Go(func(ctx context.Context) error {
+			result, err := fn(ctx, item)
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	if err := batch.Wait(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
@@ -0,0 +1,107 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBatchErrors(t *testing.T) {
+	errFailed := errors.New("operation failed")
+
+	tests := []struct {
+		name     string
+		opts     BatchOptions
+		failures []bool
+		want     []error
+	}{
+		{
+			name:     "all succeed",
+			opts:     BatchOptions{Concurrency: 1},
+			failures: []bool{false, false, false},
+			want:     nil,
+		},
+		{
+			name:     "all run without fail-fast",
+			opts:     BatchOptions{Concurrency: 1},
+			failures: []bool{false, true, false, true},
+			want:     []error{nil, errFailed, nil, errFailed},
+		},
+		{
+			name:     "fail-fast skips the remaining operations",
+			opts:     BatchOptions{Concurrency: 1, FailFast: true},
+			failures: []bool{false, true, false, false},
+			want:     []error{nil, errFailed, context.Canceled, context.Canceled},
+		},
+		{
+			name:     "fail-fast on the first operation",
+			opts:     BatchOptions{Concurrency: 1, FailFast: true},
+			failures: []bool{true, false, true},
+			want:     []error{errFailed, context.Canceled, context.Canceled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := NewBatch(context.Background(), tt.opts)
+			for _, fail := range tt.failures {
+				batch.Go(func(ctx context.Context) error {
+					if fail {
+						return errFailed
+					}
+					return nil
+				})
+			}
+			err := batch.Wait()
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Wait() = %v, want nil", err)
+				}
+				return
+			}
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) {
+				t.Fatalf("Wait() = %v, want a *BatchError", err)
+			}
+			if len(batchErr.Errors) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d", len(batchErr.Errors), len(tt.want))
+			}
+			failed := 0
+			for i, want := range tt.want {
+				got := batchErr.Errors[i]
+				if !errors.Is(got, want) {
+					t.Errorf("Errors[%d] = %v, want %v", i, got, want)
+				}
+				if want != nil {
+					failed++
+				}
+			}
+			if batchErr.Failed != failed {
+				t.Errorf("Failed = %d, want %d", batchErr.Failed, failed)
+			}
+			if !errors.Is(err, errFailed) {
+				t.Errorf("errors.Is(err, errFailed) = false for %v", err)
+			}
+			// The cause is reported rather than the cancellations it caused.
+			if !strings.Contains(err.Error(), errFailed.Error()) {
+				t.Errorf("Error() = %q, want it to contain %q", err.Error(), errFailed.Error())
+			}
+		})
+	}
+}
+
+func TestBatchMap(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	results, err := BatchMap(context.Background(), items, BatchOptions{Concurrency: 3}, func(_ context.Context, item int) (int, error) {
+		return item * item, nil
+	})
+	if err != nil {
+		t.Fatalf("BatchMap() error = %v", err)
+	}
+	for i, item := range items {
+		if results[i] != item*item {
+			t.Errorf("results[%d] = %d, want %d", i, results[i], item*item)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package lexware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is the time cached reference data is kept if Config.CacheTTL is not set.
+const DefaultCacheTTL = time.Hour
+
+// Cache stores the responses of reference data endpoints, which change almost never:
+// Countries().List, PaymentConditions().List, PostingCategories().List, PrintLayouts().List and
+// Profile().Get. Values are raw JSON response bodies. Implementations must be safe for
+// concurrent use.
+//
+// Keys are derived from the request path. If a cache backend is shared between clients of
+// different organizations, prefix the keys per organization.
+type Cache interface {
+	// Get returns the value stored under key, if present and not expired.
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	// Delete removes the value stored under key.
+	Delete(ctx context.Context, key string)
+}
+
+// cachedPaths lists the endpoints whose responses are cached.
+var cachedPaths = []string{
+	"/v1/countries",
+	"/v1/payment-conditions",
+	"/v1/posting-categories",
+	"/v1/print-layouts",
+	"/v1/profile",
+}
+
+func cacheKey(path string) string {
+	return "lexware:" + path
+}
+
+// doCachedRequest performs a GET request, serving the response from the cache if possible.
+func (c *Client) doCachedRequest(ctx context.Context, op operation, path string) ([]byte, error) {
+	if c.cache == nil {
+		return c.doRequest(ctx, op, "GET", path, nil)
+	}
+	key := cacheKey(path)
+	if !callOptionsFrom(ctx).bypassCache {
+		if body, ok := c.cache.Get(ctx, key); ok {
+			return body, nil
+		}
+	}
+	body, err := c.doRequest(ctx, op, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Set(ctx, key, body, c.cacheTTL)
+	return body, nil
+}
+
+// InvalidateCache removes all cached reference data, so the next calls fetch it from the API.
+func (c *Client) InvalidateCache(ctx context.Context) {
+	if c.cache == nil {
+		return
+	}
+	for _, path := range cachedPaths {
+		c.cache.Delete(ctx, cacheKey(path))
+	}
+}
+
+// WarmCache fetches all cached reference data from the API, e.g. at startup. It does nothing
+// if no cache is configured.
+func (c *Client) WarmCache(ctx context.Context) (err error) {
+	if c.cache == nil {
+		return nil
+	}
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	ctx, span := c.startCall(ctx, operation{name: "WarmCache"})
+	defer func() { endCall(span, err) }()
+	c.InvalidateCache(ctx)
+	if _, err := c.Countries().List(ctx); err != nil {
+		return err
+	}
+	if _, err := c.PaymentConditions().List(ctx); err != nil {
+		return err
+	}
+	if _, err := c.PostingCategories().List(ctx); err != nil {
+		return err
+	}
+	if _, err := c.PrintLayouts().List(ctx); err != nil {
+		return err
+	}
+	if _, err := c.Profile().Get(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MemoryCache is an in-memory Cache.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = memoryCacheEntry{value: value, expires: time.Now().Add(ttl)}
+}
+
+func (c *MemoryCache) Delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
@@ -0,0 +1,159 @@
+package lexware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// CallOption configures the requests of a single call. Set call options with WithCallOptions.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	headers     http.Header
+	timeout     time.Duration
+	retry       *RetryPolicy
+	bypassCache bool
+	prefetch    int
+	sort        types.SortField
+	direction   types.SortDirection
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context that applies opts to all calls made with it, in addition to
+// the call options already set on ctx. All methods of all resource clients support them:
+//
+//	ctx := lexware.WithCallOptions(ctx, lexware.Timeout(5*time.Second), lexware.NoRetry())
+//	invoice, err := client.Invoices().Get(ctx, id)
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := callOptionsFrom(ctx)
+	o.headers = o.headers.Clone()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+func callOptionsFrom(ctx context.Context) callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return o
+}
+
+// Header sets a header on all requests of the call, e.g. a correlation ID. The Authorization and
+// Content-Type headers can't be overridden.
+func Header(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		o.headers.Set(key, value)
+	}
+}
+
+// Timeout bounds the whole call: all its requests, their retries and the time spent waiting for
+// the rate limiter. For creates with an idempotency key this includes the lookups of vouchers
+// created by a failed attempt, for the All iterators fetching all pages and running the loop
+// body. Bodies of downloaded documents must be read within the timeout.
+func Timeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// Retry overrides the client's retry policy for the call. Unset fields fall back to the client's
+// policy; RetryNonIdempotent is used as given.
+func Retry(policy RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = &policy
+	}
+}
+
+// NoRetry disables retries for the call.
+func NoRetry() CallOption {
+	return Retry(RetryPolicy{MaxAttempts: 1})
+}
+
+// BypassCache makes the call fetch cached reference data from the API. The fresh response
+// replaces the cached one.
+func BypassCache() CallOption {
+	return func(o *callOptions) {
+		o.bypassCache = true
+	}
+}
+
+// Prefetch makes the All iterators fetch up to pages pages ahead of the one being iterated,
+// concurrently and within the rate limit, once the number of pages is known from the first one.
+// This speeds up walking large lists at the cost of keeping up to pages pages in memory.
+func Prefetch(pages int) CallOption {
+	return func(o *callOptions) {
+		o.prefetch = pages
+	}
+}
+
+// Sort makes the All iterators request the pages sorted by field in direction, SortAsc if empty.
+// Like types.ListOptions.Sort, it is supported by contacts and the voucherlist only; other fields
+// and endpoints make the iteration fail with ErrInvalidSort before a request is sent.
+func Sort(field types.SortField, direction types.SortDirection) CallOption {
+	return func(o *callOptions) {
+		o.sort, o.direction = field, direction
+	}
+}
+
+// withTimeout applies the Timeout call option of ctx. The returned context no longer carries it,
+// so all requests made with it share one deadline instead of starting their own.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	o := callOptionsFrom(ctx)
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	o.timeout = 0
+	return context.WithValue(ctx, callOptionsKey{}, o), cancel
+}
+
+// retryPolicyFor returns the retry policy of calls made with ctx.
+func (c *Client) retryPolicyFor(ctx context.Context) RetryPolicy {
+	override := callOptionsFrom(ctx).retry
+	if override == nil {
+		return c.retryPolicy
+	}
+	policy := c.retryPolicy
+	if override.MaxAttempts > 0 {
+		policy.MaxAttempts = override.MaxAttempts
+	}
+	if override.InitialBackoff > 0 {
+		policy.InitialBackoff = override.InitialBackoff
+	}
+	if override.MaxBackoff > 0 {
+		policy.MaxBackoff = override.MaxBackoff
+	}
+	policy.RetryNonIdempotent = override.RetryNonIdempotent
+	return policy
+}
+
+// cancelOnClose cancels the context of a request once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// setHeaders copies headers onto h, leaving the Authorization header and the Content-Type of the
+// body, e.g. the boundary of uploads, untouched.
+func setHeaders(h, headers http.Header) {
+	for key, values := range headers {
+		if key != "Authorization" && key != "Content-Type" {
+			h[key] = slices.Clone(values)
+		}
+	}
+}
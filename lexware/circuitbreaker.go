@@ -0,0 +1,209 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending a request while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("lexware: circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests pass.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests pass to test whether the API has
+	// recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed requests that opens the circuit.
+	// Requests fail if the API responds with a 5xx status, times out or can't be reached. The end
+	// of the caller's context, e.g. its deadline, is not a failure.
+	// Defaults to 5.
+	FailureThreshold int
+	// OpenDuration is the time the circuit stays open before probe requests are let through.
+	// Defaults to 30s.
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of probe requests let through while half-open. The circuit
+	// closes once all of them succeeded and opens again on the first failure. Defaults to 1.
+	HalfOpenProbes int
+	// OnStateChange is called on every state change, e.g. to alert on an outage. It is called
+	// synchronously and must not block.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker stops sending requests while the Lexware API is failing, so callers fail fast
+// with ErrCircuitOpen instead of waiting for the rate limiter and timing out one by one. A
+// CircuitBreaker may be shared between clients, e.g. those of a ClientPool.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    CircuitState
+	openedAt time.Time
+	// generation changes with every state change, so outcomes of requests let through in an
+	// earlier state are ignored.
+	generation uint64
+	failures   int
+	probes     int
+	successes  int
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = 30 * time.Second
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{config: config}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// ready returns ErrCircuitOpen while the circuit is open and not yet due for probing. It is
+// checked before waiting for the rate limiter.
+func (b *CircuitBreaker) ready() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) < b.config.OpenDuration {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// allow reserves the right to send a request and returns the generation to pass to done.
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	from := b.state
+	generation, err := b.reserve()
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+	return generation, err
+}
+
+// reserve implements allow. b.mu must be held.
+func (b *CircuitBreaker) reserve() (uint64, error) {
+	if b.state == CircuitOpen {
+		if time.Since(b.openedAt) < b.config.OpenDuration {
+			return 0, ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+	}
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.config.HalfOpenProbes {
+			return 0, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// done records the outcome of a request let through by allow.
+func (b *CircuitBreaker) done(generation uint64, outcome circuitOutcome) {
+	b.mu.Lock()
+	from := b.state
+	if generation != b.generation || outcome == outcomeNeutral {
+		if generation == b.generation && b.state == CircuitHalfOpen {
+			// Let another probe through instead.
+			b.probes--
+		}
+		b.mu.Unlock()
+		return
+	}
+	switch {
+	case outcome == outcomeFailure && b.state == CircuitHalfOpen:
+		b.setState(CircuitOpen)
+	case outcome == outcomeFailure:
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.setState(CircuitOpen)
+		}
+	case b.state == CircuitHalfOpen:
+		b.successes++
+		if b.successes >= b.config.HalfOpenProbes {
+			b.setState(CircuitClosed)
+		}
+	default:
+		b.failures = 0
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// setState changes the state and resets the counters. b.mu must be held.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.generation++
+	b.failures, b.probes, b.successes = 0, 0, 0
+	if state == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *CircuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.config.OnStateChange != nil {
+		b.config.OnStateChange(from, to)
+	}
+}
+
+type circuitOutcome int
+
+const (
+	outcomeSuccess circuitOutcome = iota
+	outcomeFailure
+	// outcomeNeutral is the outcome of requests that say nothing about the API's health, e.g.
+	// those canceled by the caller.
+	outcomeNeutral
+)
+
+// classifyOutcome returns the circuitOutcome of a single attempt sent with ctx.
+func classifyOutcome(ctx context.Context, resp *http.Response, err error) circuitOutcome {
+	if resp != nil {
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return outcomeFailure
+		}
+		return outcomeSuccess
+	}
+	// Errors returned by middleware are not the API's fault, and neither are attempts cut short by
+	// the caller's cancelation or deadline, e.g. the Timeout call option.
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || errors.Is(err, context.Canceled) || ctx.Err() != nil {
+		return outcomeNeutral
+	}
+	return outcomeFailure
+}
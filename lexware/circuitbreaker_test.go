@@ -0,0 +1,212 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+// breakerStep is an action on a CircuitBreaker in TestCircuitBreakerTransitions.
+type breakerStep struct {
+	// action is "allow", "done" or "expire", which ends the open duration.
+	action string
+	// request is the index of the allow step in the steps whose outcome done records.
+	request int
+	outcome circuitOutcome
+	// err is the error expected from allow.
+	err error
+}
+
+func allowStep(err error) breakerStep { return breakerStep{action: "allow", err: err} }
+
+func doneStep(request int, outcome circuitOutcome) breakerStep {
+	return breakerStep{action: "done", request: request, outcome: outcome}
+}
+
+var expireStep = breakerStep{action: "expire"}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	// Opens the circuit with a FailureThreshold of 2, using the requests 0 and 1.
+	open := []breakerStep{allowStep(nil), allowStep(nil), doneStep(0, outcomeFailure), doneStep(1, outcomeFailure)}
+
+	tests := []struct {
+		name        string
+		steps       []breakerStep
+		want        CircuitState
+		transitions []CircuitState
+	}{
+		{
+			name:  "success resets failures",
+			steps: []breakerStep{allowStep(nil), allowStep(nil), allowStep(nil), doneStep(0, outcomeFailure), doneStep(1, outcomeSuccess), doneStep(2, outcomeFailure)},
+			want:  CircuitClosed,
+		},
+		{
+			name:  "neutral outcomes don't count",
+			steps: []breakerStep{allowStep(nil), allowStep(nil), doneStep(0, outcomeFailure), doneStep(1, outcomeNeutral)},
+			want:  CircuitClosed,
+		},
+		{
+			name:        "consecutive failures open",
+			steps:       append(slices.Clone(open), allowStep(ErrCircuitOpen)),
+			want:        CircuitOpen,
+			transitions: []CircuitState{CircuitOpen},
+		},
+		{
+			name:        "open duration lets a probe through",
+			steps:       append(slices.Clone(open), expireStep, allowStep(nil), allowStep(ErrCircuitOpen)),
+			want:        CircuitHalfOpen,
+			transitions: []CircuitState{CircuitOpen, CircuitHalfOpen},
+		},
+		{
+			name:        "successful probe closes",
+			steps:       append(slices.Clone(open), expireStep, allowStep(nil), doneStep(5, outcomeSuccess), allowStep(nil)),
+			want:        CircuitClosed,
+			transitions: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed},
+		},
+		{
+			name:        "failed probe reopens",
+			steps:       append(slices.Clone(open), expireStep, allowStep(nil), doneStep(5, outcomeFailure), allowStep(ErrCircuitOpen)),
+			want:        CircuitOpen,
+			transitions: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen},
+		},
+		{
+			name:        "neutral probe lets another probe through",
+			steps:       append(slices.Clone(open), expireStep, allowStep(nil), doneStep(5, outcomeNeutral), allowStep(nil), allowStep(ErrCircuitOpen)),
+			want:        CircuitHalfOpen,
+			transitions: []CircuitState{CircuitOpen, CircuitHalfOpen},
+		},
+		{
+			name: "outcomes of an earlier state are ignored",
+			steps: []breakerStep{
+				allowStep(nil), allowStep(nil), allowStep(nil), doneStep(0, outcomeFailure), doneStep(1, outcomeFailure),
+				expireStep, allowStep(nil), doneStep(2, outcomeFailure), doneStep(6, outcomeSuccess),
+			},
+			want:        CircuitClosed,
+			transitions: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var transitions []CircuitState
+			b := NewCircuitBreaker(CircuitBreakerConfig{
+				FailureThreshold: 2,
+				OpenDuration:     time.Hour,
+				OnStateChange: func(_, to CircuitState) {
+					transitions = append(transitions, to)
+				},
+			})
+			generations := make(map[int]uint64)
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					generation, err := b.allow()
+					if !errors.Is(err, s.err) {
+						t.Fatalf("step %d: allow() error = %v, want %v", i, err, s.err)
+					}
+					generations[i] = generation
+				case "done":
+					b.done(generations[s.request], s.outcome)
+				case "expire":
+					b.mu.Lock()
+					b.openedAt = b.openedAt.Add(-b.config.OpenDuration)
+					b.mu.Unlock()
+				}
+			}
+			if got := b.State(); got != tt.want {
+				t.Errorf("State() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(transitions, tt.transitions) {
+				t.Errorf("transitions = %v, want %v", transitions, tt.transitions)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerReady(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Hour})
+	if err := b.ready(); err != nil {
+		t.Fatalf("ready() while closed = %v, want nil", err)
+	}
+	generation, _ := b.allow()
+	b.done(generation, outcomeFailure)
+	if err := b.ready(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("ready() while open = %v, want ErrCircuitOpen", err)
+	}
+	b.mu.Lock()
+	b.openedAt = b.openedAt.Add(-time.Hour)
+	b.mu.Unlock()
+	if err := b.ready(); err != nil {
+		t.Fatalf("ready() after the open duration = %v, want nil", err)
+	}
+}
+
+func TestClassifyOutcome(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://api.lexware.io/v1/profile", Err: err}
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   circuitOutcome
+	}{
+		{name: "200", status: 200, want: outcomeSuccess},
+		{name: "404", status: 404, err: errors.New("not found"), want: outcomeSuccess},
+		{name: "503", status: 503, err: errors.New("unavailable"), want: outcomeFailure},
+		{name: "network error", err: urlErr(io.ErrUnexpectedEOF), want: outcomeFailure},
+		{name: "client timeout", err: urlErr(context.DeadlineExceeded), want: outcomeFailure},
+		{name: "middleware error", err: errors.New("rejected by middleware"), want: outcomeNeutral},
+		{name: "canceled", ctx: canceled, err: urlErr(context.Canceled), want: outcomeNeutral},
+		{name: "caller deadline", ctx: expired, err: urlErr(context.DeadlineExceeded), want: outcomeNeutral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			var resp *http.Response
+			if tt.status != 0 {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := classifyOutcome(ctx, resp, tt.err); got != tt.want {
+				t.Errorf("classifyOutcome() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCallerTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1})
+	client, err := NewClientWithConfig(Config{BaseURL: srv.URL, APIKey: "key", RateLimit: -1, CircuitBreaker: breaker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithCallOptions(context.Background(), Timeout(10*time.Millisecond))
+	if _, err := client.Profile().Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get returned %v, want context.DeadlineExceeded", err)
+	}
+	if state := breaker.State(); state != CircuitClosed {
+		t.Errorf("the caller's timeout changed the circuit to %v", state)
+	}
+}
@@ -5,15 +5,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
-	"golang.org/x/time/rate"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -29,10 +32,26 @@ const (
 
 // Client is the main entry point to the Lexware API.
 type Client struct {
-	baseURL     string
-	apiKey      string
-	httpClient  *http.Client
-	rateLimiter *rate.Limiter
+	baseURL          string
+	tokenSource      TokenSource
+	httpClient       *http.Client
+	rateLimiter      RateLimiter
+	breaker          *CircuitBreaker
+	retryPolicy      RetryPolicy
+	handler          Handler
+	tracer           trace.Tracer
+	metrics          Metrics
+	cache            Cache
+	cacheTTL         time.Duration
+	idempotencyStore IdempotencyStore
+	idempotencyTTL   time.Duration
+	redactor         *redactor
+	// dryRun answers mutating requests without sending them, logging them to dryRunLogger.
+	dryRun       bool
+	dryRunLogger *slog.Logger
+	// onUnauthorized is called when a request finally fails with 401, after the TokenSource was
+	// asked for a new key. The ClientPool uses it to drop the client.
+	onUnauthorized func()
 
 	articles            ArticlesInterface
 	contacts            ContactsInterface
@@ -62,9 +81,55 @@ type Config struct {
 	APIKey     string
 	HTTPClient *http.Client
 	Timeout    time.Duration
+	// TokenSource provides the API key per request, e.g. to support key rotation. APIKey is
+	// ignored if set.
+	TokenSource TokenSource
 	// RateLimit specifies the maximum requests per second. Defaults to DefaultRateLimit (2).
-	// Set to 0 or negative to disable rate limiting.
+	// Set to negative to disable rate limiting.
 	RateLimit float64
+	// RateLimiter replaces the default in-memory AdaptiveRateLimiter created from RateLimit,
+	// e.g. with one shared between clients or processes. RateLimit is ignored if set.
+	RateLimiter RateLimiter
+	// HighPriorityReserve is the share of RateLimit reserved for requests with PriorityHigh,
+	// e.g. 0.25. See AdaptiveRateLimiter.SetHighPriorityReserve. Ignored if RateLimiter is set.
+	HighPriorityReserve float64
+	// CircuitBreaker makes requests fail fast with ErrCircuitOpen while the API is failing.
+	// It is disabled if nil.
+	CircuitBreaker *CircuitBreaker
+	// Retry controls how requests failing with 429, 5xx or network errors are retried.
+	// Unset fields fall back to the values of DefaultRetryPolicy.
+	Retry RetryPolicy
+	// TracerProvider enables OpenTelemetry tracing. Every call of an interface method is recorded
+	// as a span named after it, e.g. "Invoices.Create", with a child span for every HTTP request
+	// it sends. Tracing is disabled if nil.
+	TracerProvider trace.TracerProvider
+	// Metrics records request counts, latencies, retries and rate limiter wait times.
+	Metrics Metrics
+	// Logger enables logging of every request with method, path, status and duration. Request and
+	// response bodies are logged at debug level. The Authorization header is never logged.
+	Logger *slog.Logger
+	// RedactFields lists the JSON fields and query parameters whose values are redacted from logs.
+	// Defaults to DefaultRedactFields. Email addresses and IBANs are redacted from all values.
+	RedactFields []string
+	// Cache enables caching of reference data: countries, payment conditions, posting
+	// categories, print layouts and the profile. Caching is disabled if nil.
+	Cache Cache
+	// CacheTTL is the time cached reference data is kept. Defaults to DefaultCacheTTL.
+	CacheTTL time.Duration
+	// IdempotencyStore stores the idempotency keys set with WithIdempotencyKey. Defaults to a
+	// MemoryIdempotencyStore of the client.
+	IdempotencyStore IdempotencyStore
+	// IdempotencyTTL is the time idempotency keys are remembered. Defaults to DefaultIdempotencyTTL.
+	IdempotencyTTL time.Duration
+	// DryRun makes the client send GET requests only. Mutating requests (creates, updates,
+	// deletes and uploads) are built and logged with their JSON body, personal data redacted as
+	// configured with RedactFields, and answered with synthetic results: new random IDs for
+	// created resources and the sent version plus one for updates. Uploaded files are read but
+	// not logged. Only resource IDs and the encoding of bodies are checked; requests the API
+	// would reject succeed. Requests are logged to Logger, or slog.Default() if Logger is nil.
+	DryRun bool
+	// Middleware is applied to every request sent by the client, the first entry being the outermost.
+	Middleware []Middleware
 }
 
 // NewClient creates a new Lexware API client with the given API key.
@@ -74,8 +139,12 @@ func NewClient(apiKey string) (*Client, error) {
 
 // NewClientWithConfig creates a new Lexware API client with the given configuration.
 func NewClientWithConfig(config Config) (*Client, error) {
-	if config.APIKey == "" {
-		return nil, fmt.Errorf("API key is required")
+	tokenSource := config.TokenSource
+	if tokenSource == nil {
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("API key is required")
+		}
+		tokenSource = StaticTokenSource(config.APIKey)
 	}
 
 	baseURL := config.BaseURL
@@ -93,21 +162,48 @@ func NewClientWithConfig(config Config) (*Client, error) {
 	}
 
 	// Set up rate limiter
-	var rateLimiter *rate.Limiter
-	rateLimit := config.RateLimit
-	if rateLimit == 0 {
-		rateLimit = DefaultRateLimit
-	}
-	if rateLimit > 0 {
-		rateLimiter = rate.NewLimiter(rate.Limit(rateLimit), 1)
+	rateLimiter := config.RateLimiter
+	if rateLimiter == nil {
+		rateLimiter = newRateLimiter(config)
 	}
 
 	client := &Client{
 		baseURL:     baseURL,
-		apiKey:      config.APIKey,
+		tokenSource: tokenSource,
 		httpClient:  httpClient,
 		rateLimiter: rateLimiter,
+		breaker:     config.CircuitBreaker,
+		retryPolicy: config.Retry.withDefaults(),
+	}
+	client.tracer = newTracer(config.TracerProvider)
+	client.cache = config.Cache
+	client.cacheTTL = config.CacheTTL
+	if client.cacheTTL <= 0 {
+		client.cacheTTL = DefaultCacheTTL
+	}
+	client.idempotencyStore = config.IdempotencyStore
+	if client.idempotencyStore == nil {
+		client.idempotencyStore = NewMemoryIdempotencyStore()
 	}
+	client.idempotencyTTL = config.IdempotencyTTL
+	if client.idempotencyTTL <= 0 {
+		client.idempotencyTTL = DefaultIdempotencyTTL
+	}
+	client.metrics = config.Metrics
+	if client.metrics == nil {
+		client.metrics = noopMetrics{}
+	}
+	client.redactor = newRedactor(config.RedactFields)
+	client.dryRun = config.DryRun
+	client.dryRunLogger = config.Logger
+	if client.dryRunLogger == nil {
+		client.dryRunLogger = slog.Default()
+	}
+	middleware := config.Middleware
+	if config.Logger != nil {
+		middleware = append(middleware[:len(middleware):len(middleware)], loggingMiddleware(config.Logger, client.redactor))
+	}
+	client.handler = chainMiddleware(client.transport, middleware)
 
 	client.articles = &articlesClient{client: client}
 	client.contacts = &contactsClient{client: client}
@@ -133,6 +229,21 @@ func NewClientWithConfig(config Config) (*Client, error) {
 	return client, nil
 }
 
+// newRateLimiter returns the rate limiter configured by config.RateLimit and
+// config.HighPriorityReserve, or nil if rate limiting is disabled.
+func newRateLimiter(config Config) RateLimiter {
+	rateLimit := config.RateLimit
+	if rateLimit == 0 {
+		rateLimit = DefaultRateLimit
+	}
+	if rateLimit < 0 {
+		return nil
+	}
+	limiter := NewAdaptiveRateLimiter(rateLimit)
+	limiter.SetHighPriorityReserve(config.HighPriorityReserve)
+	return limiter
+}
+
 func (c *Client) Articles() ArticlesInterface                       { return c.articles }
 func (c *Client) Contacts() ContactsInterface                       { return c.contacts }
 func (c *Client) Countries() CountriesInterface                     { return c.countries }
@@ -154,37 +265,97 @@ func (c *Client) RecurringTemplates() RecurringTemplatesInterface   { return c.r
 func (c *Client) VoucherList() VoucherListInterface                 { return c.voucherList }
 func (c *Client) Vouchers() VouchersInterface                       { return c.vouchers }
 
-func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
-	// Apply rate limiting
-	if c.rateLimiter != nil {
-		if err := c.rateLimiter.Wait(ctx); err != nil {
-			return nil, fmt.Errorf("rate limiter wait failed: %w", err)
-		}
+// operation identifies the interface method a request belongs to.
+type operation struct {
+	name       string
+	resourceID string
+	// page is the requested page of list operations, paged marks those operations.
+	page  int
+	paged bool
+}
+
+func listOperation(name string, opts *types.ListOptions) operation {
+	op := operation{name: name, paged: true}
+	if opts != nil {
+		op.page = opts.Page
 	}
+	return op
+}
+
+// request describes a single logical API request, which may be sent several times when retried.
+type request struct {
+	op      operation
+	method  string
+	path    string
+	headers map[string]string
+	// body returns a fresh reader for every attempt. It is nil for requests without a body.
+	body func() (io.Reader, error)
+	// oneShot marks requests whose body can only be read once and which therefore cannot be retried.
+	oneShot bool
+	// checked marks requests whose ambiguous failures are checked by the caller before sending them
+	// again. They are never retried on network errors or 5xx responses.
+	checked bool
+}
 
+// newHTTPRequest creates the HTTP request of a single attempt.
+func (r *request) newHTTPRequest(ctx context.Context, baseURL string) (*http.Request, error) {
 	var bodyReader io.Reader
+	if r.body != nil {
+		var err error
+		if bodyReader, err = r.body(); err != nil {
+			return nil, fmt.Errorf("failed to create request body: %w", err)
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, r.method, baseURL+r.path, bodyReader)
+	if err != nil {
+		if closer, ok := bodyReader.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return httpReq, nil
+}
+
+// newJSONRequest creates a request with body marshaled as JSON.
+func newJSONRequest(op operation, method, path string, body interface{}, headers map[string]string) (*request, error) {
+	req := &request{op: op, method: method, path: path, headers: headers}
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
+		req.body = func() (io.Reader, error) { return bytes.NewReader(jsonBody), nil }
 	}
+	return req, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+func (c *Client) doRequest(ctx context.Context, op operation, method, path string, body interface{}) ([]byte, error) {
+	headers := map[string]string{"Accept": "application/json"}
+	if body != nil {
+		headers["Content-Type"] = "application/json"
+	}
+	req, err := newJSONRequest(op, method, path, body, headers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return c.do(ctx, req)
+}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "application/json")
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+// doRequestRaw sends a request and returns the response with its body unread, e.g. for document
+// downloads. Non-2xx responses are returned as *APIError.
+func (c *Client) doRequestRaw(ctx context.Context, op operation, method, path string, body interface{}, headers map[string]string) (*http.Response, error) {
+	req, err := newJSONRequest(op, method, path, body, headers)
+	if err != nil {
+		return nil, err
 	}
+	return c.send(ctx, req)
+}
 
-	resp, err := c.httpClient.Do(req)
+// do sends the request and returns the response body, or an *APIError for non-2xx responses.
+func (c *Client) do(ctx context.Context, req *request) ([]byte, error) {
+	resp, err := c.send(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -192,42 +363,201 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	return respBody, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+// send executes a request, retrying it according to the client's retry policy. Every attempt
+// passes the middleware chain. Non-2xx responses are returned as *APIError; otherwise the caller
+// is responsible for closing the response body.
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, error) {
+	if callOptionsFrom(ctx).timeout > 0 {
+		ctx, cancel := withTimeout(ctx)
+		resp, err := c.sendTraced(ctx, r)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		// The body of downloads is read after send returns.
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
 	}
+	return c.sendTraced(ctx, r)
+}
 
-	return respBody, nil
+func (c *Client) sendTraced(ctx context.Context, r *request) (resp *http.Response, err error) {
+	if !inCall(ctx) {
+		// Methods sending a single request record the call here.
+		var call trace.Span
+		ctx, call = c.startCall(ctx, r.op)
+		defer func() { endCall(call, err) }()
+	}
+	ctx, span := c.startSpan(ctx, r)
+	var stats requestStats
+	if c.dryRun && r.method != http.MethodGet {
+		resp, err = c.sendDryRun(ctx, r)
+	} else {
+		resp, err = c.sendAttempts(ctx, r, &stats)
+	}
+	endSpan(span, &stats, err)
+	return resp, err
 }
 
-func (c *Client) doRequestRaw(ctx context.Context, method, path string, body interface{}, headers map[string]string) (*http.Response, error) {
-	// Apply rate limiting
-	if c.rateLimiter != nil {
-		if err := c.rateLimiter.Wait(ctx); err != nil {
+func (c *Client) sendAttempts(ctx context.Context, r *request, stats *requestStats) (*http.Response, error) {
+	policy := c.retryPolicyFor(ctx)
+	maxAttempts := policy.MaxAttempts
+	if r.oneShot {
+		maxAttempts = 1
+	}
+	if r.checked {
+		policy.RetryNonIdempotent = false
+	}
+	refreshed := false
+
+	for attempt := 1; ; attempt++ {
+		stats.attempts = attempt
+		if c.breaker != nil {
+			// Fail fast instead of queueing up behind the rate limiter during an outage.
+			if err := c.breaker.ready(); err != nil {
+				return nil, err
+			}
+		}
+		waitStart := time.Now()
+		err := c.waitRateLimit(ctx)
+		waited := time.Since(waitStart)
+		stats.rateLimitWait += waited
+		c.metrics.ObserveRateLimitWait(r.op.name, waited)
+		if err != nil {
 			return nil, fmt.Errorf("rate limiter wait failed: %w", err)
 		}
-	}
 
-	var bodyReader io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		token, err := c.tokenSource.Token(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, fmt.Errorf("failed to get API key: %w", err)
+		}
+
+		// Reserve the breaker before building the body, which starts the writer of uploads.
+		var generation uint64
+		if c.breaker != nil {
+			if generation, err = c.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+		httpReq, err := r.newHTTPRequest(ctx, c.baseURL)
+		if err != nil {
+			if c.breaker != nil {
+				c.breaker.done(generation, outcomeNeutral)
+			}
+			return nil, err
+		}
+
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		for k, v := range r.headers {
+			httpReq.Header.Set(k, v)
+		}
+		setHeaders(httpReq.Header, callOptionsFrom(ctx).headers)
+
+		req := &Request{
+			Operation:   r.op.name,
+			ResourceID:  r.op.resourceID,
+			Attempt:     attempt,
+			HTTPRequest: httpReq,
+		}
+		start := time.Now()
+		resp, err := c.handler(req)
+		if resp == nil && httpReq.Body != nil {
+			// Middleware that aborts never hands the request to the transport, which closes the
+			// body otherwise. Upload bodies must be closed to stop their writer goroutine.
+			httpReq.Body.Close()
+		}
+		if c.breaker != nil {
+			c.breaker.done(generation, classifyOutcome(ctx, resp, err))
+		}
+		statusCode := 0
+		delay := policy.backoff(attempt)
+		if resp != nil {
+			statusCode = resp.StatusCode
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = retryAfter
+			}
+			c.reportRateLimit(statusCode, delay)
+		}
+		stats.statusCode = statusCode
+		c.metrics.ObserveRequest(r.op.name, statusCode, time.Since(start))
+
+		if statusCode == http.StatusUnauthorized && !refreshed && !r.oneShot && ctx.Err() == nil {
+			refreshed = true
+			if newToken, err := c.tokenSource.Token(ctx); err == nil && newToken != token {
+				// The key was rotated, retry once right away with the new one.
+				resp.Body.Close()
+				maxAttempts++
+				continue
+			}
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil || !policy.shouldRetry(r.method, resp, err) {
+			if err != nil {
+				if resp != nil {
+					resp.Body.Close()
+				}
+				if statusCode == http.StatusUnauthorized && c.onUnauthorized != nil {
+					c.onUnauthorized()
+				}
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+		recordRetry(ctx, attempt, resp, delay)
+		c.metrics.ObserveRetry(r.op.name, statusCode)
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
 		}
-		bodyReader = bytes.NewReader(jsonBody)
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+// transport is the innermost Handler. It sends the request and decodes non-2xx responses into
+// an *APIError, keeping the body readable for middleware.
+func (c *Client) transport(req *Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req.HTTPRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, newAPIError(resp.StatusCode, body)
 	}
 
-	return c.httpClient.Do(req)
+	return resp, nil
+}
+
+// waitRateLimit blocks until the client is allowed to send the next request.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.rateLimiter == nil {
+		return ctx.Err()
+	}
+	return c.rateLimiter.Wait(ctx)
+}
+
+// reportRateLimit tells the rate limiter whether the API throttled a request.
+func (c *Client) reportRateLimit(statusCode int, retryAfter time.Duration) {
+	if c.rateLimiter == nil {
+		return
+	}
+	if statusCode == http.StatusTooManyRequests {
+		c.rateLimiter.ReportThrottled(retryAfter)
+	} else {
+		c.rateLimiter.ReportSuccess()
+	}
 }
 
 func buildQueryString(params map[string]string) string {
@@ -246,9 +576,24 @@ func buildQueryString(params map[string]string) string {
 	return ""
 }
 
-func addPagination(params map[string]string, opts *types.ListOptions) {
+// sortFields lists the fields the list endpoints that support sorting can be sorted by.
+var sortFields = map[string][]types.SortField{
+	"/v1/contacts": {types.SortByName},
+	"/v1/voucherlist": {
+		types.SortByVoucherDate, types.SortByVoucherNumber, types.SortByCreatedDate, types.SortByUpdatedDate,
+		types.SortByDueDate, types.SortByContactName, types.SortByTotalAmount, types.SortByOpenAmount,
+	},
+}
+
+// ErrInvalidSort is returned for sort options a list endpoint doesn't support. No request is sent
+// in that case, so unlike ErrValidation it never stems from the API.
+var ErrInvalidSort = errors.New("lexware: invalid sort option")
+
+// addPagination adds the paging and sort parameters of opts for the list endpoint at path. Sort
+// options the endpoint doesn't support fail with ErrInvalidSort.
+func addPagination(params map[string]string, opts *types.ListOptions, path string) error {
 	if opts == nil {
-		return
+		return nil
 	}
 	if opts.Page > 0 {
 		params["page"] = strconv.Itoa(opts.Page)
@@ -256,19 +601,23 @@ func addPagination(params map[string]string, opts *types.ListOptions) {
 	if opts.Size > 0 {
 		params["size"] = strconv.Itoa(opts.Size)
 	}
+	if opts.Sort == "" {
+		if opts.Direction != "" {
+			return fmt.Errorf("%w: sort direction %s requires a sort field", ErrInvalidSort, opts.Direction)
+		}
+		return nil
+	}
+	if !slices.Contains(sortFields[path], opts.Sort) {
+		return fmt.Errorf("%w: %s can't be sorted by %s", ErrInvalidSort, path, opts.Sort)
+	}
+	direction := opts.Direction
+	switch direction {
+	case "":
+		direction = types.SortAsc
+	case types.SortAsc, types.SortDesc:
+	default:
+		return fmt.Errorf("%w: invalid sort direction %s", ErrInvalidSort, direction)
+	}
+	params["sort"] = string(opts.Sort) + "," + string(direction)
+	return nil
 }
-
-// APIError represents an error returned by the Lexware API.
-type APIError struct {
-	StatusCode int
-	Body       string
-}
-
-func (e *APIError) Error() string {
-	return fmt.Sprintf("Lexware API error (status %d): %s", e.StatusCode, e.Body)
-}
-
-func (e *APIError) IsNotFound() bool     { return e.StatusCode == http.StatusNotFound }
-func (e *APIError) IsConflict() bool     { return e.StatusCode == http.StatusConflict }
-func (e *APIError) IsRateLimited() bool  { return e.StatusCode == http.StatusTooManyRequests }
-func (e *APIError) IsUnauthorized() bool { return e.StatusCode == http.StatusUnauthorized }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"strconv"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
@@ -12,19 +13,15 @@ import (
 type contactsClient struct{ client *Client }
 
 func (c *contactsClient) Create(ctx context.Context, contact *types.ContactCreateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "POST", "/v1/contacts", contact)
-	if err != nil {
-		return nil, err
-	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "Contacts.Create"}, "/v1/contacts", contact)
 }
 
 func (c *contactsClient) Get(ctx context.Context, id string) (*types.Contact, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/contacts/"+id, nil)
+	path, err := idPath("/v1/contacts", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Contacts.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +33,11 @@ func (c *contactsClient) Get(ctx context.Context, id string) (*types.Contact, er
 }
 
 func (c *contactsClient) Update(ctx context.Context, id string, contact *types.ContactUpdateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "PUT", "/v1/contacts/"+id, contact)
+	path, err := idPath("/v1/contacts", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Contacts.Update", resourceID: id}, "PUT", path, contact)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +50,9 @@ func (c *contactsClient) Update(ctx context.Context, id string, contact *types.C
 
 func (c *contactsClient) List(ctx context.Context, opts *types.ListOptions, filter *types.ContactFilterOptions) (*types.Page[types.Contact], error) {
 	params := make(map[string]string)
-	addPagination(params, opts)
+	if err := addPagination(params, opts, "/v1/contacts"); err != nil {
+		return nil, err
+	}
 	if filter != nil {
 		if filter.Email != "" {
 			params["email"] = filter.Email
@@ -67,7 +70,7 @@ func (c *contactsClient) List(ctx context.Context, opts *types.ListOptions, filt
 			params["vendor"] = "true"
 		}
 	}
-	body, err := c.client.doRequest(ctx, "GET", "/v1/contacts"+buildQueryString(params), nil)
+	body, err := c.client.doRequest(ctx, listOperation("Contacts.List", opts), "GET", "/v1/contacts"+buildQueryString(params), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -77,3 +80,11 @@ func (c *contactsClient) List(ctx context.Context, opts *types.ListOptions, filt
 	}
 	return &page, nil
 }
+
+func (c *contactsClient) All(ctx context.Context, filter *types.ContactFilterOptions) iter.Seq2[types.Contact, error] {
+	return traceAll(c.client, ctx, "Contacts.All", func(ctx context.Context) iter.Seq2[types.Contact, error] {
+		return ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.Contact], error) {
+			return c.List(ctx, opts, filter)
+		}, func(contact types.Contact) string { return contact.ID })
+	})
+}
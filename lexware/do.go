@@ -0,0 +1,90 @@
+package lexware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Do sends a request to an endpoint this library doesn't support yet, e.g.
+//
+//	var result types.ActionResult
+//	err := client.Do(ctx, "POST", "/v1/new-endpoint", payload, &result)
+//
+// path is relative to the base URL and may include a query string. in is marshaled as JSON if it
+// is not nil; the response is unmarshaled into out if out is not nil. The request passes the
+// same authentication, rate limiting, retries, circuit breaker and middleware as all other calls,
+// and non-2xx responses are returned as *APIError.
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	if err := checkPath(path); err != nil {
+		return err
+	}
+	body, err := c.doRequest(ctx, operation{name: "Client.Do"}, method, path, in)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// DoRaw is like Do, but streams the request and the response body, e.g. for uploads and
+// downloads of endpoints this library doesn't support yet. header is added to the request; the
+// Authorization header can't be overridden.
+//
+// If body implements io.ReadSeeker, it is rewound for every attempt and the request can be
+// retried; otherwise it is sent only once. body is never closed, even if it implements
+// io.Closer. The caller must close the body of the returned response.
+func (c *Client) DoRaw(ctx context.Context, method, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	if err := checkPath(path); err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(header))
+	for key, values := range header {
+		if len(values) > 0 && http.CanonicalHeaderKey(key) != "Authorization" {
+			headers[key] = values[0]
+		}
+	}
+	req := &request{op: operation{name: "Client.DoRaw"}, method: method, path: path, headers: headers}
+	if body != nil {
+		seeker, replayable := body.(io.ReadSeeker)
+		var start int64
+		if replayable {
+			var err error
+			if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+				return nil, fmt.Errorf("failed to determine body offset: %w", err)
+			}
+		}
+		req.oneShot = !replayable
+		req.body = func() (io.Reader, error) {
+			if replayable {
+				if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("failed to rewind body: %w", err)
+				}
+			}
+			if _, ok := body.(io.Closer); ok {
+				// The transport closes request bodies, but body belongs to the caller and is read
+				// again by retries.
+				return io.NopCloser(body), nil
+			}
+			// Keeps in-memory bodies like *bytes.Reader recognizable for the Content-Length.
+			return body, nil
+		}
+	}
+	return c.send(ctx, req)
+}
+
+// checkPath makes sure that requests built from path stay on the API host.
+func checkPath(path string) error {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return fmt.Errorf("invalid path %q: must start with a single /", path)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package lexware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by APIError via errors.Is.
+var (
+	ErrNotFound     = errors.New("lexware: resource not found")
+	ErrConflict     = errors.New("lexware: conflicting resource version")
+	ErrValidation   = errors.New("lexware: validation failed")
+	ErrRateLimited  = errors.New("lexware: rate limit exceeded")
+	ErrUnauthorized = errors.New("lexware: unauthorized")
+)
+
+// APIError represents an error returned by the Lexware API.
+type APIError struct {
+	StatusCode int
+	// Body is the raw response body.
+	Body string
+
+	// ErrorText is the HTTP reason phrase reported by the API, e.g. "Not Acceptable".
+	ErrorText string
+	// Message is the human readable error message reported by the API.
+	Message string
+	// Path is the request path reported by the API.
+	Path string
+	// TraceID identifies the request in Lexware's logs. Older endpoints report a request ID instead.
+	TraceID string
+	// Timestamp is the time of the error as reported by the API.
+	Timestamp string
+	// Issues lists the field-level problems reported by the API.
+	Issues []Issue
+}
+
+// Issue describes a single problem with a request, usually a failed validation of one field.
+type Issue struct {
+	// I18nKey is the translation key of the issue, e.g. "missing_entity".
+	I18nKey string
+	// Source is the offending field, e.g. "lineItems[0].unitPrice.taxRatePercentage".
+	Source string
+	// Type is the kind of issue, e.g. "validation_failure" or "NOTNULL".
+	Type string
+	// Message is a human readable description of the issue.
+	Message string
+}
+
+// errorPayload covers both error formats of the Lexware API: the current one with a details list
+// and the legacy one with an IssueList.
+type errorPayload struct {
+	Timestamp string `json:"timestamp"`
+	Status    int    `json:"status"`
+	Error     string `json:"error"`
+	Path      string `json:"path"`
+	TraceID   string `json:"traceId"`
+	RequestID string `json:"requestId"`
+	Message   string `json:"message"`
+	Details   []struct {
+		Violation string `json:"violation"`
+		Field     string `json:"field"`
+		Message   string `json:"message"`
+	} `json:"details"`
+	IssueList []struct {
+		I18nKey string `json:"i18nKey"`
+		Source  string `json:"source"`
+		Type    string `json:"type"`
+	} `json:"IssueList"`
+}
+
+// newAPIError creates an APIError from a non-2xx response, decoding the error payload if possible.
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Body: string(body)}
+
+	var payload errorPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return apiErr
+	}
+
+	apiErr.ErrorText = payload.Error
+	apiErr.Message = payload.Message
+	apiErr.Path = payload.Path
+	apiErr.Timestamp = payload.Timestamp
+	apiErr.TraceID = payload.TraceID
+	if apiErr.TraceID == "" {
+		apiErr.TraceID = payload.RequestID
+	}
+	for _, d := range payload.Details {
+		apiErr.Issues = append(apiErr.Issues, Issue{Source: d.Field, Type: d.Violation, Message: d.Message})
+	}
+	for _, i := range payload.IssueList {
+		apiErr.Issues = append(apiErr.Issues, Issue{I18nKey: i.I18nKey, Source: i.Source, Type: i.Type})
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" && len(e.Issues) == 0 {
+		return fmt.Sprintf("Lexware API error (status %d): %s", e.StatusCode, e.Body)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Lexware API error (status %d)", e.StatusCode)
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	for _, issue := range e.Issues {
+		b.WriteString("; ")
+		b.WriteString(issue.String())
+	}
+	return b.String()
+}
+
+func (i Issue) String() string {
+	var parts []string
+	for _, s := range []string{i.Source, i.Type, i.I18nKey, i.Message} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// Is makes APIError match the sentinel errors of this package, e.g. errors.Is(err, ErrNotFound).
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.IsNotFound()
+	case ErrConflict:
+		return e.IsConflict()
+	case ErrValidation:
+		return e.IsValidation()
+	case ErrRateLimited:
+		return e.IsRateLimited()
+	case ErrUnauthorized:
+		return e.IsUnauthorized()
+	}
+	return false
+}
+
+func (e *APIError) IsNotFound() bool     { return e.StatusCode == http.StatusNotFound }
+func (e *APIError) IsConflict() bool     { return e.StatusCode == http.StatusConflict }
+func (e *APIError) IsRateLimited() bool  { return e.StatusCode == http.StatusTooManyRequests }
+func (e *APIError) IsUnauthorized() bool { return e.StatusCode == http.StatusUnauthorized }
+
+// IsValidation reports whether the request was rejected as invalid. Lexware uses 406 for most
+// validation failures, 400 and 422 are reported for malformed requests.
+func (e *APIError) IsValidation() bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusNotAcceptable, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
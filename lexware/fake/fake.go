@@ -0,0 +1,560 @@
+// Package fake provides in-memory implementations of all resource interfaces of the lexware
+// package for unit tests. No HTTP requests are sent.
+//
+//	client := fake.NewClient()
+//	contactID, err := client.AddContact(types.Contact{Company: &types.Company{Name: "ACME GmbH"}})
+//	client.SetError("Invoices.Create", &lexware.APIError{StatusCode: http.StatusNotAcceptable})
+//
+//	svc := NewBillingService(client.Contacts(), client.Invoices()) // Takes lexware interfaces
+//	...
+//	if n := client.CallCount("Invoices.Create"); n != 1 {
+//	    t.Errorf("Invoices.Create called %d times", n)
+//	}
+//
+// All resources share one state: an invoice created through Invoices shows up in VoucherList,
+// a rendered document can be downloaded through Files, and so on. The fakes follow the rules of
+// the real API where they matter for callers:
+//
+//   - Created resources get an ID, version 1 and creation dates. Updates must send the current
+//     version and fail with 409 Conflict otherwise.
+//   - Sales vouchers created with finalize set are open and numbered per voucher type, e.g.
+//     "RE0001" for the first invoice. Drafts can't be pursued and their documents can't be
+//     rendered (406 Not Acceptable). Dunnings are always drafts but can be rendered.
+//   - Missing resources fail with 404 Not Found.
+//
+// Errors are returned as *lexware.APIError, so errors.Is works with the sentinel errors of the
+// lexware package. Amounts are stored as sent; totals are not calculated.
+//
+// Resources are stored and returned as copies made through their JSON encoding. The Add and Set
+// methods, like the fakes, fail if a value can't be encoded, e.g. an amount of NaN.
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/internal/uuid"
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+const defaultPageSize = 25
+
+// Call is a recorded method call.
+type Call struct {
+	// Method is the interface and method called, e.g. "Invoices.Create".
+	Method string
+	// Args are the arguments of the call without the context.
+	Args []interface{}
+}
+
+// Client holds the shared state of all fakes and hands them out through the same methods as
+// lexware.Client. It is safe for concurrent use.
+type Client struct {
+	mu      sync.Mutex
+	calls   []Call
+	errors  map[string]error
+	numbers map[string]int
+
+	profile           types.Profile
+	countries         []types.Country
+	paymentConditions []types.PaymentCondition
+	postingCategories []types.PostingCategory
+	printLayouts      []types.PrintLayout
+
+	articles           *store[types.Article]
+	contacts           *store[types.Contact]
+	eventSubscriptions *store[types.EventSubscription]
+	recurringTemplates *store[types.RecurringTemplate]
+	vouchers           *store[types.Voucher]
+	salesVouchers      *store[salesVoucher]
+	files              map[string][]byte
+	payments           map[string]types.Payment
+	// voucherContacts holds the contact of each voucher, which types.Voucher doesn't carry.
+	voucherContacts map[string]string
+	// voucherIDs lists sales vouchers and vouchers in the order they were created.
+	voucherIDs []string
+}
+
+// NewClient creates a Client with empty state and reference data.
+func NewClient() *Client {
+	return &Client{
+		errors:             make(map[string]error),
+		numbers:            make(map[string]int),
+		profile:            types.Profile{OrganizationID: uuid.New(), CompanyName: "Fake GmbH", TaxType: string(types.TaxTypeNet)},
+		articles:           newStore[types.Article](),
+		contacts:           newStore[types.Contact](),
+		eventSubscriptions: newStore[types.EventSubscription](),
+		recurringTemplates: newStore[types.RecurringTemplate](),
+		vouchers:           newStore[types.Voucher](),
+		salesVouchers:      newStore[salesVoucher](),
+		files:              make(map[string][]byte),
+		payments:           make(map[string]types.Payment),
+		voucherContacts:    make(map[string]string),
+	}
+}
+
+func (c *Client) Articles() lexware.ArticlesInterface   { return &articles{c} }
+func (c *Client) Contacts() lexware.ContactsInterface   { return &contacts{c} }
+func (c *Client) Countries() lexware.CountriesInterface { return &countries{c} }
+func (c *Client) CreditNotes() lexware.CreditNotesInterface {
+	return &creditNotes{newSalesVouchers[types.CreditNote](c, creditNoteKind)}
+}
+func (c *Client) DeliveryNotes() lexware.DeliveryNotesInterface {
+	return &deliveryNotes{newSalesVouchers[types.DeliveryNote](c, deliveryNoteKind)}
+}
+func (c *Client) DownPaymentInvoices() lexware.DownPaymentInvoicesInterface {
+	return &downPaymentInvoices{newSalesVouchers[types.DownPaymentInvoice](c, downPaymentInvoiceKind)}
+}
+func (c *Client) Dunnings() lexware.DunningsInterface {
+	return &dunnings{newSalesVouchers[types.Dunning](c, dunningKind)}
+}
+func (c *Client) EventSubscriptions() lexware.EventSubscriptionsInterface {
+	return &eventSubscriptions{c}
+}
+func (c *Client) Files() lexware.FilesInterface { return &files{c} }
+func (c *Client) Invoices() lexware.InvoicesInterface {
+	return &invoices{newSalesVouchers[types.Invoice](c, invoiceKind)}
+}
+func (c *Client) OrderConfirmations() lexware.OrderConfirmationsInterface {
+	return &orderConfirmations{newSalesVouchers[types.OrderConfirmation](c, orderConfirmationKind)}
+}
+func (c *Client) Payments() lexware.PaymentsInterface                   { return &payments{c} }
+func (c *Client) PaymentConditions() lexware.PaymentConditionsInterface { return &paymentConditions{c} }
+func (c *Client) PostingCategories() lexware.PostingCategoriesInterface { return &postingCategories{c} }
+func (c *Client) PrintLayouts() lexware.PrintLayoutsInterface           { return &printLayouts{c} }
+func (c *Client) Profile() lexware.ProfileInterface                     { return &profile{c} }
+func (c *Client) Quotations() lexware.QuotationsInterface {
+	return &quotations{newSalesVouchers[types.Quotation](c, quotationKind)}
+}
+func (c *Client) RecurringTemplates() lexware.RecurringTemplatesInterface {
+	return &recurringTemplates{c}
+}
+func (c *Client) VoucherList() lexware.VoucherListInterface { return &voucherList{c} }
+func (c *Client) Vouchers() lexware.VouchersInterface       { return &vouchers{c} }
+
+// SetError makes all following calls of method fail with err, e.g. "Invoices.Create". The
+// calls are still recorded. A nil err removes the injected error.
+func (c *Client) SetError(method string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.errors, method)
+		return
+	}
+	c.errors[method] = err
+}
+
+// Calls returns all recorded calls in the order they were made.
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// CallCount returns the number of recorded calls of method, e.g. "Contacts.Get".
+func (c *Client) CallCount(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, call := range c.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// ResetCalls discards all recorded calls.
+func (c *Client) ResetCalls() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = nil
+}
+
+// record records a call and returns the error to fail it with, if any. c.mu must be held.
+func (c *Client) record(ctx context.Context, method string, args ...interface{}) error {
+	c.calls = append(c.calls, Call{Method: method, Args: args})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.errors[method]
+}
+
+// SetProfile sets the profile returned by Profile().Get.
+func (c *Client) SetProfile(profile types.Profile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.profile = profile
+}
+
+// SetCountries sets the countries returned by Countries().List.
+func (c *Client) SetCountries(countries []types.Country) error {
+	countries, err := clone(countries)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.countries = countries
+	return nil
+}
+
+// SetPaymentConditions sets the payment conditions returned by PaymentConditions().List.
+func (c *Client) SetPaymentConditions(conditions []types.PaymentCondition) error {
+	conditions, err := clone(conditions)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paymentConditions = conditions
+	return nil
+}
+
+// SetPostingCategories sets the posting categories returned by PostingCategories().List.
+func (c *Client) SetPostingCategories(categories []types.PostingCategory) error {
+	categories, err := clone(categories)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.postingCategories = categories
+	return nil
+}
+
+// SetPrintLayouts sets the print layouts returned by PrintLayouts().List.
+func (c *Client) SetPrintLayouts(layouts []types.PrintLayout) error {
+	layouts, err := clone(layouts)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.printLayouts = layouts
+	return nil
+}
+
+// SetPayment sets the payment information returned by Payments().Get for a voucher.
+func (c *Client) SetPayment(voucherID string, payment types.Payment) error {
+	payment, err := clone(payment)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payments[voucherID] = payment
+	return nil
+}
+
+// AddContact stores a contact as is and returns its ID, which is generated if not set.
+func (c *Client) AddContact(contact types.Contact) (string, error) {
+	contact, err := clone(contact)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	contact.ID = idOrNew(contact.ID)
+	c.contacts.put(contact.ID, contact)
+	return contact.ID, nil
+}
+
+// AddArticle stores an article as is and returns its ID, which is generated if not set.
+func (c *Client) AddArticle(article types.Article) (string, error) {
+	article, err := clone(article)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	article.ID = idOrNew(article.ID)
+	c.articles.put(article.ID, article)
+	return article.ID, nil
+}
+
+// AddVoucher stores a bookkeeping voucher as is and returns its ID, which is generated if not set.
+func (c *Client) AddVoucher(voucher types.Voucher) (string, error) {
+	voucher, err := clone(voucher)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	voucher.ID = idOrNew(voucher.ID)
+	if _, ok := c.vouchers.get(voucher.ID); !ok {
+		c.voucherIDs = append(c.voucherIDs, voucher.ID)
+	}
+	c.vouchers.put(voucher.ID, voucher)
+	return voucher.ID, nil
+}
+
+// AddRecurringTemplate stores a recurring template as is and returns its ID, which is generated
+// if not set.
+func (c *Client) AddRecurringTemplate(template types.RecurringTemplate) (string, error) {
+	template, err := clone(template)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	template.ID = idOrNew(template.ID)
+	c.recurringTemplates.put(template.ID, template)
+	return template.ID, nil
+}
+
+// AddEventSubscription stores an event subscription as is and returns its ID, which is
+// generated if not set.
+func (c *Client) AddEventSubscription(subscription types.EventSubscription) (string, error) {
+	subscription, err := clone(subscription)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	subscription.SubscriptionID = idOrNew(subscription.SubscriptionID)
+	c.eventSubscriptions.put(subscription.SubscriptionID, subscription)
+	return subscription.SubscriptionID, nil
+}
+
+// AddFile stores a file and returns its ID.
+func (c *Client) AddFile(content []byte) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := uuid.New()
+	c.files[id] = bytes.Clone(content)
+	return id
+}
+
+// AddInvoice stores an invoice as is and returns its ID, which is generated if not set.
+func (c *Client) AddInvoice(invoice types.Invoice) (string, error) {
+	return c.addSalesVoucher(invoiceKind, invoice.ID, invoice)
+}
+
+// AddQuotation stores a quotation as is and returns its ID, which is generated if not set.
+func (c *Client) AddQuotation(quotation types.Quotation) (string, error) {
+	return c.addSalesVoucher(quotationKind, quotation.ID, quotation)
+}
+
+// AddCreditNote stores a credit note as is and returns its ID, which is generated if not set.
+func (c *Client) AddCreditNote(creditNote types.CreditNote) (string, error) {
+	return c.addSalesVoucher(creditNoteKind, creditNote.ID, creditNote)
+}
+
+// AddDeliveryNote stores a delivery note as is and returns its ID, which is generated if not set.
+func (c *Client) AddDeliveryNote(deliveryNote types.DeliveryNote) (string, error) {
+	return c.addSalesVoucher(deliveryNoteKind, deliveryNote.ID, deliveryNote)
+}
+
+// AddDunning stores a dunning as is and returns its ID, which is generated if not set.
+func (c *Client) AddDunning(dunning types.Dunning) (string, error) {
+	return c.addSalesVoucher(dunningKind, dunning.ID, dunning)
+}
+
+// AddOrderConfirmation stores an order confirmation as is and returns its ID, which is generated
+// if not set.
+func (c *Client) AddOrderConfirmation(orderConfirmation types.OrderConfirmation) (string, error) {
+	return c.addSalesVoucher(orderConfirmationKind, orderConfirmation.ID, orderConfirmation)
+}
+
+// AddDownPaymentInvoice stores a down payment invoice as is and returns its ID, which is
+// generated if not set.
+func (c *Client) AddDownPaymentInvoice(invoice types.DownPaymentInvoice) (string, error) {
+	return c.addSalesVoucher(downPaymentInvoiceKind, invoice.ID, invoice)
+}
+
+func (c *Client) addSalesVoucher(kind *salesVoucherKind, id string, voucher interface{}) (string, error) {
+	doc, err := toDocument(voucher)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id = idOrNew(id)
+	doc["id"] = id
+	if _, ok := c.salesVouchers.get(id); !ok {
+		c.voucherIDs = append(c.voucherIDs, id)
+	}
+	c.salesVouchers.put(id, salesVoucher{kind: kind, doc: doc})
+	return id, nil
+}
+
+// store holds resources in the order they were added.
+type store[T any] struct {
+	ids   []string
+	items map[string]T
+}
+
+func newStore[T any]() *store[T] {
+	return &store[T]{items: make(map[string]T)}
+}
+
+func (s *store[T]) put(id string, v T) {
+	if _, ok := s.items[id]; !ok {
+		s.ids = append(s.ids, id)
+	}
+	s.items[id] = v
+}
+
+func (s *store[T]) get(id string) (T, bool) {
+	v, ok := s.items[id]
+	return v, ok
+}
+
+func (s *store[T]) delete(id string) {
+	delete(s.items, id)
+	for i, existing := range s.ids {
+		if existing == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			return
+		}
+	}
+}
+
+// list returns the resources matching filter in the order they were added.
+func (s *store[T]) list(filter func(T) bool) []T {
+	var items []T
+	for _, id := range s.ids {
+		if v := s.items[id]; filter == nil || filter(v) {
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// paginate returns the page of items selected by opts.
+func paginate[T any](items []T, opts *types.ListOptions) (*types.Page[T], error) {
+	page, size := 0, defaultPageSize
+	if opts != nil {
+		page = max(opts.Page, 0)
+		if opts.Size > 0 {
+			size = opts.Size
+		}
+	}
+	totalPages := (len(items) + size - 1) / size
+	content := []T{}
+	if start := page * size; start < len(items) {
+		var err error
+		if content, err = clone(items[start:min(start+size, len(items))]); err != nil {
+			return nil, err
+		}
+	}
+	return &types.Page[T]{
+		Content:          content,
+		First:            page == 0,
+		Last:             page >= totalPages-1,
+		TotalPages:       totalPages,
+		TotalElements:    len(items),
+		NumberOfElements: len(content),
+		Size:             size,
+		Number:           page,
+	}, nil
+}
+
+// sortItems sorts items by the field requested in opts. cmps maps the fields the endpoint can be
+// sorted by to their comparison, it is nil for endpoints that can't be sorted. Other fields fail
+// with lexware.ErrInvalidSort like with the real client.
+func sortItems[T any](items []T, opts *types.ListOptions, cmps map[types.SortField]func(a, b T) int) error {
+	if opts == nil || opts.Sort == "" && opts.Direction == "" {
+		return nil
+	}
+	if opts.Sort == "" {
+		return fmt.Errorf("%w: sort direction %s requires a sort field", lexware.ErrInvalidSort, opts.Direction)
+	}
+	compare, ok := cmps[opts.Sort]
+	if !ok {
+		return fmt.Errorf("%w: can't sort by %s", lexware.ErrInvalidSort, opts.Sort)
+	}
+	switch opts.Direction {
+	case "", types.SortAsc:
+		slices.SortStableFunc(items, compare)
+	case types.SortDesc:
+		slices.SortStableFunc(items, func(a, b T) int { return compare(b, a) })
+	default:
+		return fmt.Errorf("%w: invalid sort direction %s", lexware.ErrInvalidSort, opts.Direction)
+	}
+	return nil
+}
+
+func notFound(resource, id string) error {
+	return apiError(http.StatusNotFound, "%s %s does not exist", resource, id)
+}
+
+func conflict(version, current int) error {
+	return apiError(http.StatusConflict, "version %d is outdated, the current version is %d", version, current)
+}
+
+func apiError(status int, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	return &lexware.APIError{
+		StatusCode: status,
+		Body:       message,
+		ErrorText:  http.StatusText(status),
+		Message:    message,
+	}
+}
+
+func actionResult(collection, id string, created, updated time.Time, version int) *types.ActionResult {
+	return &types.ActionResult{
+		ID:          id,
+		ResourceURI: lexware.DefaultBaseURL + "/v1/" + collection + "/" + id,
+		CreatedDate: created,
+		UpdatedDate: updated,
+		Version:     version,
+	}
+}
+
+// clone returns a deep copy of v, so callers can't modify the stored state.
+func clone[T any](v T) (T, error) {
+	var c T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return c, fmt.Errorf("failed to copy %T: %w", v, err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("failed to copy %T: %w", v, err)
+	}
+	return c, nil
+}
+
+// convert copies the fields of from to a value of type T with the same JSON names, e.g. from a
+// create request to the created resource.
+func convert[T any](from interface{}) (T, error) {
+	var to T
+	data, err := json.Marshal(from)
+	if err != nil {
+		return to, fmt.Errorf("failed to convert %T: %w", from, err)
+	}
+	if err := json.Unmarshal(data, &to); err != nil {
+		return to, fmt.Errorf("failed to convert %T to %T: %w", from, to, err)
+	}
+	return to, nil
+}
+
+func readAll(content io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	return data, nil
+}
+
+func idOrNew(id string) string {
+	if id != "" {
+		return id
+	}
+	return uuid.New()
+}
+
+// now returns the current time at the millisecond precision of the API.
+func now() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
@@ -0,0 +1,227 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/lexware/fake"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+func addContact(t *testing.T, client *fake.Client, name string) string {
+	t.Helper()
+	id, err := client.AddContact(types.Contact{Company: &types.Company{Name: name}})
+	if err != nil {
+		t.Fatalf("failed to add contact: %v", err)
+	}
+	return id
+}
+
+func TestSetError(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClient()
+	id := addContact(t, client, "ACME GmbH")
+
+	injected := &lexware.APIError{StatusCode: http.StatusServiceUnavailable}
+	client.SetError("Contacts.Get", injected)
+	if _, err := client.Contacts().Get(ctx, id); err != injected {
+		t.Fatalf("Get returned %v, want the injected error", err)
+	}
+	if _, err := client.Articles().Get(ctx, id); !errors.Is(err, lexware.ErrNotFound) {
+		t.Errorf("Articles.Get returned %v, want ErrNotFound", err)
+	}
+	if n := client.CallCount("Contacts.Get"); n != 1 {
+		t.Errorf("failed call was recorded %d times, want 1", n)
+	}
+
+	client.SetError("Contacts.Get", nil)
+	contact, err := client.Contacts().Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get returned %v after removing the error", err)
+	}
+	if contact.Company.Name != "ACME GmbH" {
+		t.Errorf("got company %q, want ACME GmbH", contact.Company.Name)
+	}
+}
+
+func TestCalls(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClient()
+
+	result, err := client.Contacts().Create(ctx, &types.ContactCreateRequest{Company: &types.Company{Name: "ACME GmbH"}})
+	if err != nil {
+		t.Fatalf("Create returned %v", err)
+	}
+	for range 2 {
+		if _, err := client.Contacts().Get(ctx, result.ID); err != nil {
+			t.Fatalf("Get returned %v", err)
+		}
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := client.Contacts().Get(canceled, result.ID); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get with a canceled context returned %v, want context.Canceled", err)
+	}
+
+	var methods []string
+	for _, call := range client.Calls() {
+		methods = append(methods, call.Method)
+	}
+	if want := []string{"Contacts.Create", "Contacts.Get", "Contacts.Get", "Contacts.Get"}; !slices.Equal(methods, want) {
+		t.Errorf("got calls %v, want %v", methods, want)
+	}
+	if args := client.Calls()[1].Args; len(args) != 1 || args[0] != result.ID {
+		t.Errorf("got Get arguments %v, want [%s]", args, result.ID)
+	}
+	if n := client.CallCount("Contacts.Get"); n != 3 {
+		t.Errorf("CallCount(Contacts.Get) = %d, want 3", n)
+	}
+	if n := client.CallCount("Contacts.Update"); n != 0 {
+		t.Errorf("CallCount(Contacts.Update) = %d, want 0", n)
+	}
+
+	client.ResetCalls()
+	if calls := client.Calls(); len(calls) != 0 {
+		t.Errorf("got %d calls after ResetCalls", len(calls))
+	}
+}
+
+func TestAdd(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClient()
+
+	contact := types.Contact{ID: "8f1c6c2e-4d7a-4b3e-9a51-0c2d8e7f6a90", Company: &types.Company{Name: "ACME GmbH"}}
+	id, err := client.AddContact(contact)
+	if err != nil {
+		t.Fatalf("AddContact returned %v", err)
+	}
+	if id != contact.ID {
+		t.Errorf("AddContact returned ID %s, want %s", id, contact.ID)
+	}
+	// The fake stores and returns copies.
+	contact.Company.Name = "Changed"
+	got, err := client.Contacts().Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get returned %v", err)
+	}
+	got.Company.Name = "Changed"
+	if got, _ = client.Contacts().Get(ctx, id); got.Company.Name != "ACME GmbH" {
+		t.Errorf("stored contact was changed to %q", got.Company.Name)
+	}
+
+	if id := addContact(t, client, "Globex AG"); id == "" || id == contact.ID {
+		t.Errorf("AddContact generated ID %q", id)
+	}
+
+	if _, err := client.AddVoucher(types.Voucher{TotalGrossAmount: math.NaN()}); err == nil {
+		t.Error("AddVoucher accepted an amount of NaN")
+	}
+	page, err := client.VoucherList().List(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("List returned %v", err)
+	}
+	if page.TotalElements != 0 {
+		t.Errorf("failed AddVoucher stored %d vouchers", page.TotalElements)
+	}
+
+	if err := client.SetCountries([]types.Country{{CountryCode: "DE"}}); err != nil {
+		t.Fatalf("SetCountries returned %v", err)
+	}
+	countries, err := client.Countries().List(ctx)
+	if err != nil {
+		t.Fatalf("Countries.List returned %v", err)
+	}
+	if len(countries) != 1 || countries[0].CountryCode != "DE" {
+		t.Errorf("got countries %+v, want DE", countries)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClient()
+	var ids []string
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		ids = append(ids, addContact(t, client, name))
+	}
+
+	tests := []struct {
+		page       int
+		want       []string
+		first      bool
+		last       bool
+		totalPages int
+	}{
+		{page: 0, want: ids[:2], first: true, totalPages: 3},
+		{page: 1, want: ids[2:4], totalPages: 3},
+		{page: 2, want: ids[4:], last: true, totalPages: 3},
+		{page: 3, want: nil, last: true, totalPages: 3},
+	}
+	for _, tt := range tests {
+		page, err := client.Contacts().List(ctx, &types.ListOptions{Page: tt.page, Size: 2}, nil)
+		if err != nil {
+			t.Fatalf("List(page %d) returned %v", tt.page, err)
+		}
+		var got []string
+		for _, contact := range page.Content {
+			got = append(got, contact.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("page %d: got %v, want %v", tt.page, got, tt.want)
+		}
+		if page.First != tt.first || page.Last != tt.last || page.TotalPages != tt.totalPages || page.TotalElements != 5 {
+			t.Errorf("page %d: got first %t, last %t, %d pages, %d elements", tt.page, page.First, page.Last, page.TotalPages, page.TotalElements)
+		}
+	}
+}
+
+func TestSorting(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClient()
+	for _, name := range []string{"Globex AG", "ACME GmbH", "Initech GmbH"} {
+		addContact(t, client, name)
+	}
+	for _, amount := range []float64{200, 100, 300} {
+		if _, err := client.AddInvoice(types.Invoice{TotalPrice: &types.TotalPrice{TotalGrossAmount: amount}}); err != nil {
+			t.Fatalf("AddInvoice returned %v", err)
+		}
+	}
+
+	contacts, err := client.Contacts().List(ctx, &types.ListOptions{Sort: types.SortByName, Direction: types.SortDesc}, nil)
+	if err != nil {
+		t.Fatalf("Contacts.List returned %v", err)
+	}
+	var names []string
+	for _, contact := range contacts.Content {
+		names = append(names, contact.Company.Name)
+	}
+	if want := []string{"Initech GmbH", "Globex AG", "ACME GmbH"}; !slices.Equal(names, want) {
+		t.Errorf("got contacts %v, want %v", names, want)
+	}
+
+	vouchers, err := client.VoucherList().List(ctx, &types.ListOptions{Sort: types.SortByTotalAmount}, nil)
+	if err != nil {
+		t.Fatalf("VoucherList.List returned %v", err)
+	}
+	var amounts []float64
+	for _, item := range vouchers.Content {
+		amounts = append(amounts, item.TotalAmount)
+	}
+	if want := []float64{100, 200, 300}; !slices.Equal(amounts, want) {
+		t.Errorf("got amounts %v, want %v", amounts, want)
+	}
+
+	for _, opts := range []*types.ListOptions{
+		{Sort: types.SortByVoucherDate},
+		{Direction: types.SortAsc},
+		{Sort: types.SortByName, Direction: "UP"},
+	} {
+		if _, err := client.Contacts().List(ctx, opts, nil); !errors.Is(err, lexware.ErrInvalidSort) {
+			t.Errorf("List(%+v) returned %v, want ErrInvalidSort", opts, err)
+		}
+	}
+}
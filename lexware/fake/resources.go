@@ -0,0 +1,782 @@
+package fake
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/internal/uuid"
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+var (
+	_ lexware.ArticlesInterface            = (*articles)(nil)
+	_ lexware.ContactsInterface            = (*contacts)(nil)
+	_ lexware.CountriesInterface           = (*countries)(nil)
+	_ lexware.CreditNotesInterface         = (*creditNotes)(nil)
+	_ lexware.DeliveryNotesInterface       = (*deliveryNotes)(nil)
+	_ lexware.DownPaymentInvoicesInterface = (*downPaymentInvoices)(nil)
+	_ lexware.DunningsInterface            = (*dunnings)(nil)
+	_ lexware.EventSubscriptionsInterface  = (*eventSubscriptions)(nil)
+	_ lexware.FilesInterface               = (*files)(nil)
+	_ lexware.InvoicesInterface            = (*invoices)(nil)
+	_ lexware.OrderConfirmationsInterface  = (*orderConfirmations)(nil)
+	_ lexware.PaymentsInterface            = (*payments)(nil)
+	_ lexware.PaymentConditionsInterface   = (*paymentConditions)(nil)
+	_ lexware.PostingCategoriesInterface   = (*postingCategories)(nil)
+	_ lexware.PrintLayoutsInterface        = (*printLayouts)(nil)
+	_ lexware.ProfileInterface             = (*profile)(nil)
+	_ lexware.QuotationsInterface          = (*quotations)(nil)
+	_ lexware.RecurringTemplatesInterface  = (*recurringTemplates)(nil)
+	_ lexware.VoucherListInterface         = (*voucherList)(nil)
+	_ lexware.VouchersInterface            = (*vouchers)(nil)
+)
+
+const (
+	firstCustomerNumber = 10000
+	firstVendorNumber   = 70000
+)
+
+// Articles
+type articles struct{ c *Client }
+
+func (f *articles) Create(ctx context.Context, article *types.ArticleCreateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Articles.Create", article); err != nil {
+		return nil, err
+	}
+	created, err := convert[types.Article](article)
+	if err != nil {
+		return nil, err
+	}
+	created.ID, created.OrganizationID, created.Version = uuid.New(), f.c.profile.OrganizationID, 1
+	created.CreatedDate, created.UpdatedDate = now(), now()
+	f.c.articles.put(created.ID, created)
+	return actionResult("articles", created.ID, created.CreatedDate, created.UpdatedDate, created.Version), nil
+}
+
+func (f *articles) Get(ctx context.Context, id string) (*types.Article, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Articles.Get", id); err != nil {
+		return nil, err
+	}
+	article, ok := f.c.articles.get(id)
+	if !ok {
+		return nil, notFound("article", id)
+	}
+	article, err := clone(article)
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+func (f *articles) Update(ctx context.Context, id string, article *types.ArticleUpdateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Articles.Update", id, article); err != nil {
+		return nil, err
+	}
+	existing, ok := f.c.articles.get(id)
+	if !ok {
+		return nil, notFound("article", id)
+	}
+	if article.Version != existing.Version {
+		return nil, conflict(article.Version, existing.Version)
+	}
+	updated, err := convert[types.Article](article)
+	if err != nil {
+		return nil, err
+	}
+	updated.ID, updated.OrganizationID, updated.Archived = existing.ID, existing.OrganizationID, existing.Archived
+	updated.CreatedDate, updated.UpdatedDate = existing.CreatedDate, now()
+	updated.Version = existing.Version + 1
+	f.c.articles.put(id, updated)
+	return actionResult("articles", id, updated.CreatedDate, updated.UpdatedDate, updated.Version), nil
+}
+
+func (f *articles) Delete(ctx context.Context, id string) error {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Articles.Delete", id); err != nil {
+		return err
+	}
+	if _, ok := f.c.articles.get(id); !ok {
+		return notFound("article", id)
+	}
+	f.c.articles.delete(id)
+	return nil
+}
+
+func (f *articles) List(ctx context.Context, opts *types.ListOptions, filter *types.ArticleFilterOptions) (*types.Page[types.Article], error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Articles.List", opts, filter); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &types.ArticleFilterOptions{}
+	}
+	items := f.c.articles.list(func(a types.Article) bool {
+		return (filter.ArticleNumber == "" || a.ArticleNumber == filter.ArticleNumber) &&
+			(filter.GTIN == "" || a.GTIN == filter.GTIN) &&
+			(filter.Type == "" || a.Type == filter.Type)
+	})
+	if err := sortItems(items, opts, nil); err != nil {
+		return nil, err
+	}
+	return paginate(items, opts)
+}
+
+func (f *articles) All(ctx context.Context, filter *types.ArticleFilterOptions) iter.Seq2[types.Article, error] {
+	return lexware.ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.Article], error) {
+		return f.List(ctx, opts, filter)
+	}, func(a types.Article) string { return a.ID })
+}
+
+// Contacts
+type contacts struct{ c *Client }
+
+func (f *contacts) Create(ctx context.Context, contact *types.ContactCreateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Contacts.Create", contact); err != nil {
+		return nil, err
+	}
+	created, err := convert[types.Contact](contact)
+	if err != nil {
+		return nil, err
+	}
+	created.ID, created.OrganizationID, created.Version = uuid.New(), f.c.profile.OrganizationID, 1
+	f.c.numberRoles(created.Roles, nil)
+	f.c.contacts.put(created.ID, created)
+	date := now()
+	return actionResult("contacts", created.ID, date, date, created.Version), nil
+}
+
+// numberRoles numbers new customer and vendor roles. Numbers of existing roles are kept.
+func (c *Client) numberRoles(roles, existing *types.ContactRoles) {
+	if roles == nil {
+		return
+	}
+	if existing == nil {
+		existing = &types.ContactRoles{}
+	}
+	if roles.Customer != nil {
+		if existing.Customer != nil {
+			roles.Customer.Number = existing.Customer.Number
+		} else {
+			roles.Customer.Number = firstCustomerNumber + c.numbers["contact.customer"]
+			c.numbers["contact.customer"]++
+		}
+	}
+	if roles.Vendor != nil {
+		if existing.Vendor != nil {
+			roles.Vendor.Number = existing.Vendor.Number
+		} else {
+			roles.Vendor.Number = firstVendorNumber + c.numbers["contact.vendor"]
+			c.numbers["contact.vendor"]++
+		}
+	}
+}
+
+func (f *contacts) Get(ctx context.Context, id string) (*types.Contact, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Contacts.Get", id); err != nil {
+		return nil, err
+	}
+	contact, ok := f.c.contacts.get(id)
+	if !ok {
+		return nil, notFound("contact", id)
+	}
+	contact, err := clone(contact)
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (f *contacts) Update(ctx context.Context, id string, contact *types.ContactUpdateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Contacts.Update", id, contact); err != nil {
+		return nil, err
+	}
+	existing, ok := f.c.contacts.get(id)
+	if !ok {
+		return nil, notFound("contact", id)
+	}
+	if contact.Version != existing.Version {
+		return nil, conflict(contact.Version, existing.Version)
+	}
+	updated, err := convert[types.Contact](contact)
+	if err != nil {
+		return nil, err
+	}
+	updated.ID, updated.OrganizationID, updated.Archived = existing.ID, existing.OrganizationID, existing.Archived
+	updated.Version = existing.Version + 1
+	f.c.numberRoles(updated.Roles, existing.Roles)
+	f.c.contacts.put(id, updated)
+	date := now()
+	return actionResult("contacts", id, date, date, updated.Version), nil
+}
+
+func (f *contacts) List(ctx context.Context, opts *types.ListOptions, filter *types.ContactFilterOptions) (*types.Page[types.Contact], error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Contacts.List", opts, filter); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &types.ContactFilterOptions{}
+	}
+	items := f.c.contacts.list(func(c types.Contact) bool {
+		if filter.Email != "" && !hasEmail(c, filter.Email) {
+			return false
+		}
+		if filter.Name != "" && !strings.Contains(strings.ToLower(contactName(c)), strings.ToLower(filter.Name)) {
+			return false
+		}
+		roles := c.Roles
+		if roles == nil {
+			roles = &types.ContactRoles{}
+		}
+		if filter.Number != 0 &&
+			!(roles.Customer != nil && roles.Customer.Number == filter.Number) &&
+			!(roles.Vendor != nil && roles.Vendor.Number == filter.Number) {
+			return false
+		}
+		return (!filter.Customer || roles.Customer != nil) && (!filter.Vendor || roles.Vendor != nil)
+	})
+	if err := sortItems(items, opts, map[types.SortField]func(a, b types.Contact) int{
+		types.SortByName: func(a, b types.Contact) int { return strings.Compare(contactName(a), contactName(b)) },
+	}); err != nil {
+		return nil, err
+	}
+	return paginate(items, opts)
+}
+
+func (f *contacts) All(ctx context.Context, filter *types.ContactFilterOptions) iter.Seq2[types.Contact, error] {
+	return lexware.ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.Contact], error) {
+		return f.List(ctx, opts, filter)
+	}, func(contact types.Contact) string { return contact.ID })
+}
+
+// contactName returns the company name or the full name of a contact.
+func contactName(c types.Contact) string {
+	if c.Company != nil && c.Company.Name != "" {
+		return c.Company.Name
+	}
+	if c.Person != nil {
+		return strings.TrimSpace(c.Person.FirstName + " " + c.Person.LastName)
+	}
+	return ""
+}
+
+func hasEmail(c types.Contact, email string) bool {
+	if c.EmailAddresses == nil {
+		return false
+	}
+	email = strings.ToLower(email)
+	for _, addresses := range [][]string{c.EmailAddresses.Business, c.EmailAddresses.Office, c.EmailAddresses.Private, c.EmailAddresses.Other} {
+		for _, address := range addresses {
+			if strings.Contains(strings.ToLower(address), email) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Countries
+type countries struct{ c *Client }
+
+func (f *countries) List(ctx context.Context) ([]types.Country, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Countries.List"); err != nil {
+		return nil, err
+	}
+	return clone(f.c.countries)
+}
+
+// Event Subscriptions
+type eventSubscriptions struct{ c *Client }
+
+func (f *eventSubscriptions) Create(ctx context.Context, subscription *types.EventSubscriptionCreateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "EventSubscriptions.Create", subscription); err != nil {
+		return nil, err
+	}
+	for _, existing := range f.c.eventSubscriptions.list(nil) {
+		if existing.EventType == subscription.EventType {
+			return nil, apiError(http.StatusConflict, "an event subscription for %s already exists", subscription.EventType)
+		}
+	}
+	created := types.EventSubscription{
+		SubscriptionID: uuid.New(),
+		OrganizationID: f.c.profile.OrganizationID,
+		CreatedDate:    now(),
+		EventType:      subscription.EventType,
+		CallbackURL:    subscription.CallbackURL,
+	}
+	f.c.eventSubscriptions.put(created.SubscriptionID, created)
+	return actionResult("event-subscriptions", created.SubscriptionID, created.CreatedDate, created.CreatedDate, 0), nil
+}
+
+func (f *eventSubscriptions) Get(ctx context.Context, id string) (*types.EventSubscription, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "EventSubscriptions.Get", id); err != nil {
+		return nil, err
+	}
+	subscription, ok := f.c.eventSubscriptions.get(id)
+	if !ok {
+		return nil, notFound("event subscription", id)
+	}
+	return &subscription, nil
+}
+
+func (f *eventSubscriptions) List(ctx context.Context) ([]types.EventSubscription, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "EventSubscriptions.List"); err != nil {
+		return nil, err
+	}
+	return f.c.eventSubscriptions.list(nil), nil
+}
+
+func (f *eventSubscriptions) Delete(ctx context.Context, id string) error {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "EventSubscriptions.Delete", id); err != nil {
+		return err
+	}
+	if _, ok := f.c.eventSubscriptions.get(id); !ok {
+		return notFound("event subscription", id)
+	}
+	f.c.eventSubscriptions.delete(id)
+	return nil
+}
+
+// Files
+type files struct{ c *Client }
+
+func (f *files) Upload(ctx context.Context, filename string, content io.Reader, fileType types.FileUploadType) (*types.FileUploadResponse, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Files.Upload", filename, content, fileType); err != nil {
+		return nil, err
+	}
+	data, err := readAll(content)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New()
+	f.c.files[id] = data
+	return &types.FileUploadResponse{ID: id}, nil
+}
+
+func (f *files) Download(ctx context.Context, id string) (io.ReadCloser, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Files.Download", id); err != nil {
+		return nil, err
+	}
+	data, ok := f.c.files[id]
+	if !ok {
+		return nil, notFound("file", id)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Payments
+type payments struct{ c *Client }
+
+// Get returns the payment set with SetPayment, or derives it from the voucher: finalized
+// vouchers are unpaid.
+func (f *payments) Get(ctx context.Context, id string) (*types.Payment, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Payments.Get", id); err != nil {
+		return nil, err
+	}
+	if payment, ok := f.c.payments[id]; ok {
+		payment, err := clone(payment)
+		if err != nil {
+			return nil, err
+		}
+		return &payment, nil
+	}
+
+	item, ok, err := f.c.voucherListItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, notFound("voucher", id)
+	}
+	if item.VoucherStatus == types.VoucherStatusDraft {
+		return nil, apiError(http.StatusNotAcceptable, "payments of draft vouchers are not available")
+	}
+	status := "openRevenue"
+	switch item.VoucherType {
+	case types.VoucherTypeCreditNote, types.VoucherTypeSalesCreditNote, types.VoucherTypePurchaseInvoice:
+		status = "openExpense"
+	}
+	if item.OpenAmount == 0 {
+		status = "balanced"
+	}
+	return &types.Payment{
+		OpenAmount:    item.OpenAmount,
+		Currency:      item.Currency,
+		PaymentStatus: status,
+		VoucherType:   string(item.VoucherType),
+		VoucherID:     id,
+		VoucherNumber: item.VoucherNumber,
+		VoucherDate:   item.VoucherDate,
+	}, nil
+}
+
+// Payment Conditions
+type paymentConditions struct{ c *Client }
+
+func (f *paymentConditions) List(ctx context.Context) ([]types.PaymentCondition, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "PaymentConditions.List"); err != nil {
+		return nil, err
+	}
+	return clone(f.c.paymentConditions)
+}
+
+// Posting Categories
+type postingCategories struct{ c *Client }
+
+func (f *postingCategories) List(ctx context.Context) ([]types.PostingCategory, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "PostingCategories.List"); err != nil {
+		return nil, err
+	}
+	return clone(f.c.postingCategories)
+}
+
+// Print Layouts
+type printLayouts struct{ c *Client }
+
+func (f *printLayouts) List(ctx context.Context) ([]types.PrintLayout, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "PrintLayouts.List"); err != nil {
+		return nil, err
+	}
+	return clone(f.c.printLayouts)
+}
+
+// Profile
+type profile struct{ c *Client }
+
+func (f *profile) Get(ctx context.Context) (*types.Profile, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Profile.Get"); err != nil {
+		return nil, err
+	}
+	profile, err := clone(f.c.profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Recurring Templates
+type recurringTemplates struct{ c *Client }
+
+func (f *recurringTemplates) Get(ctx context.Context, id string) (*types.RecurringTemplate, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "RecurringTemplates.Get", id); err != nil {
+		return nil, err
+	}
+	template, ok := f.c.recurringTemplates.get(id)
+	if !ok {
+		return nil, notFound("recurring template", id)
+	}
+	template, err := clone(template)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (f *recurringTemplates) List(ctx context.Context, opts *types.ListOptions) (*types.Page[types.RecurringTemplate], error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "RecurringTemplates.List", opts); err != nil {
+		return nil, err
+	}
+	items := f.c.recurringTemplates.list(nil)
+	if err := sortItems(items, opts, nil); err != nil {
+		return nil, err
+	}
+	return paginate(items, opts)
+}
+
+func (f *recurringTemplates) All(ctx context.Context) iter.Seq2[types.RecurringTemplate, error] {
+	return lexware.ListAll(ctx, f.List, func(t types.RecurringTemplate) string { return t.ID })
+}
+
+// Vouchers
+type vouchers struct{ c *Client }
+
+func (f *vouchers) Create(ctx context.Context, voucher *types.VoucherCreateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Vouchers.Create", voucher); err != nil {
+		return nil, err
+	}
+	created, err := convert[types.Voucher](voucher)
+	if err != nil {
+		return nil, err
+	}
+	created.ID, created.OrganizationID, created.Version = uuid.New(), f.c.profile.OrganizationID, 1
+	created.VoucherStatus = types.VoucherStatusOpen
+	created.CreatedDate, created.UpdatedDate = now(), now()
+	f.c.vouchers.put(created.ID, created)
+	f.c.voucherContacts[created.ID] = voucher.ContactID
+	f.c.voucherIDs = append(f.c.voucherIDs, created.ID)
+	return actionResult("vouchers", created.ID, created.CreatedDate, created.UpdatedDate, created.Version), nil
+}
+
+func (f *vouchers) Get(ctx context.Context, id string) (*types.Voucher, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Vouchers.Get", id); err != nil {
+		return nil, err
+	}
+	voucher, ok := f.c.vouchers.get(id)
+	if !ok {
+		return nil, notFound("voucher", id)
+	}
+	voucher, err := clone(voucher)
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+func (f *vouchers) Update(ctx context.Context, id string, voucher *types.VoucherUpdateRequest) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Vouchers.Update", id, voucher); err != nil {
+		return nil, err
+	}
+	existing, ok := f.c.vouchers.get(id)
+	if !ok {
+		return nil, notFound("voucher", id)
+	}
+	if voucher.Version != existing.Version {
+		return nil, conflict(voucher.Version, existing.Version)
+	}
+	updated, err := convert[types.Voucher](voucher)
+	if err != nil {
+		return nil, err
+	}
+	updated.ID, updated.OrganizationID, updated.Type = existing.ID, existing.OrganizationID, existing.Type
+	updated.VoucherStatus, updated.Files = existing.VoucherStatus, existing.Files
+	updated.CreatedDate, updated.UpdatedDate = existing.CreatedDate, now()
+	updated.Version = existing.Version + 1
+	f.c.vouchers.put(id, updated)
+	f.c.voucherContacts[id] = voucher.ContactID
+	return actionResult("vouchers", id, updated.CreatedDate, updated.UpdatedDate, updated.Version), nil
+}
+
+func (f *vouchers) List(ctx context.Context, opts *types.ListOptions, filter *types.VoucherFilterOptions) (*types.Page[types.Voucher], error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Vouchers.List", opts, filter); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &types.VoucherFilterOptions{}
+	}
+	items := f.c.vouchers.list(func(v types.Voucher) bool {
+		return (filter.VoucherNumber == "" || v.VoucherNumber == filter.VoucherNumber) &&
+			(filter.VoucherStatus == "" || v.VoucherStatus == filter.VoucherStatus) &&
+			(filter.ContactID == "" || f.c.voucherContacts[v.ID] == filter.ContactID)
+	})
+	if err := sortItems(items, opts, nil); err != nil {
+		return nil, err
+	}
+	return paginate(items, opts)
+}
+
+func (f *vouchers) All(ctx context.Context, filter *types.VoucherFilterOptions) iter.Seq2[types.Voucher, error] {
+	return lexware.ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.Voucher], error) {
+		return f.List(ctx, opts, filter)
+	}, func(v types.Voucher) string { return v.ID })
+}
+
+func (f *vouchers) UploadFile(ctx context.Context, id string, filename string, content io.Reader) error {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "Vouchers.UploadFile", id, filename, content); err != nil {
+		return err
+	}
+	voucher, ok := f.c.vouchers.get(id)
+	if !ok {
+		return notFound("voucher", id)
+	}
+	data, err := readAll(content)
+	if err != nil {
+		return err
+	}
+	fileID := uuid.New()
+	f.c.files[fileID] = data
+	voucher.Files = append(voucher.Files, types.VoucherFile{ID: fileID})
+	f.c.vouchers.put(id, voucher)
+	return nil
+}
+
+// Voucher List
+type voucherList struct{ c *Client }
+
+func (f *voucherList) List(ctx context.Context, opts *types.ListOptions, filter *types.VoucherListFilterOptions) (*types.Page[types.VoucherListItem], error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, "VoucherList.List", opts, filter); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &types.VoucherListFilterOptions{}
+	}
+	inRange := func(date, from, to string) bool {
+		date = datePart(date)
+		return (from == "" || date >= from) && (to == "" || date <= to)
+	}
+
+	var items []types.VoucherListItem
+	for _, id := range f.c.voucherIDs {
+		item, ok, err := f.c.voucherListItem(id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if (filter.VoucherType == "" || item.VoucherType == filter.VoucherType) &&
+			(filter.VoucherStatus == "" || item.VoucherStatus == filter.VoucherStatus) &&
+			(filter.Archived == nil || item.Archived == *filter.Archived) &&
+			(filter.ContactID == "" || item.ContactID == filter.ContactID) &&
+			inRange(item.VoucherDate, filter.VoucherDateFrom, filter.VoucherDateTo) &&
+			inRange(item.CreatedDate, filter.CreatedDateFrom, filter.CreatedDateTo) &&
+			inRange(item.UpdatedDate, filter.UpdatedDateFrom, filter.UpdatedDateTo) {
+			items = append(items, item)
+		}
+	}
+	byString := func(field func(types.VoucherListItem) string) func(a, b types.VoucherListItem) int {
+		return func(a, b types.VoucherListItem) int { return strings.Compare(field(a), field(b)) }
+	}
+	if err := sortItems(items, opts, map[types.SortField]func(a, b types.VoucherListItem) int{
+		types.SortByVoucherDate:   byString(func(item types.VoucherListItem) string { return item.VoucherDate }),
+		types.SortByVoucherNumber: byString(func(item types.VoucherListItem) string { return item.VoucherNumber }),
+		types.SortByCreatedDate:   byString(func(item types.VoucherListItem) string { return item.CreatedDate }),
+		types.SortByUpdatedDate:   byString(func(item types.VoucherListItem) string { return item.UpdatedDate }),
+		types.SortByDueDate:       byString(func(item types.VoucherListItem) string { return item.DueDate }),
+		types.SortByContactName:   byString(func(item types.VoucherListItem) string { return item.ContactName }),
+		types.SortByTotalAmount:   func(a, b types.VoucherListItem) int { return cmp.Compare(a.TotalAmount, b.TotalAmount) },
+		types.SortByOpenAmount:    func(a, b types.VoucherListItem) int { return cmp.Compare(a.OpenAmount, b.OpenAmount) },
+	}); err != nil {
+		return nil, err
+	}
+	return paginate(items, opts)
+}
+
+func (f *voucherList) All(ctx context.Context, filter *types.VoucherListFilterOptions) iter.Seq2[types.VoucherListItem, error] {
+	return lexware.ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.VoucherListItem], error) {
+		return f.List(ctx, opts, filter)
+	}, func(item types.VoucherListItem) string { return item.ID })
+}
+
+// voucherListItem returns the voucherlist entry of a sales voucher or voucher. f.c.mu must be held.
+func (c *Client) voucherListItem(id string) (types.VoucherListItem, bool, error) {
+	if v, ok := c.salesVouchers.get(id); ok {
+		doc := v.doc
+		item, err := convert[types.VoucherListItem](doc)
+		if err != nil {
+			return types.VoucherListItem{}, false, err
+		}
+		item.VoucherType = v.kind.voucherType
+		item.VoucherDate = voucherDate(doc)
+		item.CreatedDate = timestamp(doc["createdDate"])
+		item.UpdatedDate = timestamp(doc["updatedDate"])
+		if address, ok := doc["address"].(map[string]interface{}); ok {
+			item.ContactID, _ = address["contactId"].(string)
+			item.ContactName, _ = address["name"].(string)
+		}
+		if price, ok := doc["totalPrice"].(map[string]interface{}); ok {
+			item.TotalAmount, _ = price["totalGrossAmount"].(float64)
+			item.Currency, _ = price["currency"].(string)
+		}
+		if item.VoucherStatus == types.VoucherStatusOpen {
+			item.OpenAmount = item.TotalAmount
+		}
+		return item, true, nil
+	}
+	if v, ok := c.vouchers.get(id); ok {
+		item := types.VoucherListItem{
+			ID:            v.ID,
+			VoucherType:   v.Type,
+			VoucherStatus: v.VoucherStatus,
+			VoucherNumber: v.VoucherNumber,
+			VoucherDate:   v.VoucherDate.Format("2006-01-02"),
+			CreatedDate:   timestamp(v.CreatedDate),
+			UpdatedDate:   timestamp(v.UpdatedDate),
+			ContactID:     c.voucherContacts[id],
+			TotalAmount:   v.TotalGrossAmount,
+			Currency:      "EUR",
+		}
+		if v.DueDate != nil {
+			item.DueDate = v.DueDate.Format("2006-01-02")
+		}
+		if contact, ok := c.contacts.get(item.ContactID); ok {
+			item.ContactName = contactName(contact)
+		}
+		if item.VoucherStatus == types.VoucherStatusOpen {
+			item.OpenAmount = item.TotalAmount
+		}
+		return item, true, nil
+	}
+	return types.VoucherListItem{}, false, nil
+}
+
+// timestamp returns a date of a stored resource in its JSON form. Documents hold the dates of
+// created vouchers as time.Time and those of added ones as decoded strings.
+func timestamp(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// datePart returns the date of an RFC 3339 timestamp, e.g. "2024-03-01".
+func datePart(s string) string {
+	if len(s) > 10 {
+		return s[:10]
+	}
+	return s
+}
@@ -0,0 +1,291 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/internal/uuid"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// salesVoucherKind describes one type of sales voucher.
+type salesVoucherKind struct {
+	name        string
+	collection  string
+	voucherType types.VoucherType
+	// prefix starts the numbers of finalized vouchers. Kinds without prefix are never finalized.
+	prefix string
+}
+
+var (
+	quotationKind          = &salesVoucherKind{name: "Quotations", collection: "quotations", voucherType: types.VoucherTypeQuotation, prefix: "AG"}
+	invoiceKind            = &salesVoucherKind{name: "Invoices", collection: "invoices", voucherType: types.VoucherTypeInvoice, prefix: "RE"}
+	creditNoteKind         = &salesVoucherKind{name: "CreditNotes", collection: "credit-notes", voucherType: types.VoucherTypeCreditNote, prefix: "GS"}
+	deliveryNoteKind       = &salesVoucherKind{name: "DeliveryNotes", collection: "delivery-notes", voucherType: types.VoucherTypeDeliveryNote, prefix: "LS"}
+	orderConfirmationKind  = &salesVoucherKind{name: "OrderConfirmations", collection: "order-confirmations", voucherType: types.VoucherTypeOrderConfirmation, prefix: "AB"}
+	dunningKind            = &salesVoucherKind{name: "Dunnings", collection: "dunnings", voucherType: "dunning"}
+	downPaymentInvoiceKind = &salesVoucherKind{name: "DownPaymentInvoices", collection: "down-payment-invoices", voucherType: types.VoucherTypeDownPaymentInvoice, prefix: "AR"}
+)
+
+// salesVoucher is a stored sales voucher of any kind. Its fields are kept as decoded JSON, as
+// the voucher types share most fields but no Go type.
+type salesVoucher struct {
+	kind *salesVoucherKind
+	doc  map[string]interface{}
+}
+
+func (v salesVoucher) string(key string) string {
+	s, _ := v.doc[key].(string)
+	return s
+}
+
+func (v salesVoucher) status() types.VoucherStatus {
+	return types.VoucherStatus(v.string("voucherStatus"))
+}
+
+// salesVouchers implements the methods shared by the sales voucher fakes. T is the voucher type.
+type salesVouchers[T any] struct {
+	c    *Client
+	kind *salesVoucherKind
+}
+
+func newSalesVouchers[T any](c *Client, kind *salesVoucherKind) *salesVouchers[T] {
+	return &salesVouchers[T]{c: c, kind: kind}
+}
+
+// create stores a new sales voucher from a create request, pursuing precedingID if set.
+func (f *salesVouchers[T]) create(ctx context.Context, method string, precedingID string, req interface{}, finalize bool, args ...interface{}) (*types.ActionResult, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, f.kind.name+"."+method, args...); err != nil {
+		return nil, err
+	}
+
+	if f.kind == dunningKind && precedingID == "" {
+		return nil, apiError(http.StatusNotAcceptable, "dunnings require a preceding invoice")
+	}
+	var preceding salesVoucher
+	if precedingID != "" {
+		var ok bool
+		if preceding, ok = f.c.salesVouchers.get(precedingID); !ok {
+			return nil, notFound("sales voucher", precedingID)
+		}
+		if preceding.status() == types.VoucherStatusDraft {
+			return nil, apiError(http.StatusNotAcceptable, "preceding sales voucher %s is a draft", precedingID)
+		}
+		if f.kind == dunningKind && preceding.kind != invoiceKind {
+			return nil, apiError(http.StatusNotAcceptable, "dunnings can only be created for invoices")
+		}
+	}
+
+	doc, err := toDocument(req)
+	if err != nil {
+		return nil, err
+	}
+	id, date := uuid.New(), now()
+	doc["id"] = id
+	doc["organizationId"] = f.c.profile.OrganizationID
+	doc["createdDate"] = date
+	doc["updatedDate"] = date
+	doc["version"] = 1
+	doc["archived"] = false
+	doc["voucherStatus"] = string(types.VoucherStatusDraft)
+	if finalize && f.kind.prefix != "" {
+		f.c.numbers[f.kind.collection]++
+		doc["voucherStatus"] = string(types.VoucherStatusOpen)
+		doc["voucherNumber"] = fmt.Sprintf("%s%04d", f.kind.prefix, f.c.numbers[f.kind.collection])
+	}
+	voucher := salesVoucher{kind: f.kind, doc: doc}
+	if precedingID != "" {
+		relate(voucher, preceding)
+		relate(preceding, voucher)
+	}
+
+	f.c.salesVouchers.put(id, voucher)
+	f.c.voucherIDs = append(f.c.voucherIDs, id)
+	return actionResult(f.kind.collection, id, date, date, 1), nil
+}
+
+// relate adds related to the related vouchers of voucher.
+func relate(voucher, related salesVoucher) {
+	relatedVouchers, _ := voucher.doc["relatedVouchers"].([]interface{})
+	voucher.doc["relatedVouchers"] = append(relatedVouchers, map[string]interface{}{
+		"id":            related.string("id"),
+		"voucherNumber": related.string("voucherNumber"),
+		"voucherType":   string(related.kind.voucherType),
+	})
+}
+
+// voucher returns the stored voucher with id if it is of the fake's kind. f.c.mu must be held.
+func (f *salesVouchers[T]) voucher(id string) (salesVoucher, error) {
+	voucher, ok := f.c.salesVouchers.get(id)
+	if !ok || voucher.kind != f.kind {
+		return salesVoucher{}, notFound(string(f.kind.voucherType), id)
+	}
+	return voucher, nil
+}
+
+func (f *salesVouchers[T]) Get(ctx context.Context, id string) (*T, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, f.kind.name+".Get", id); err != nil {
+		return nil, err
+	}
+	voucher, err := f.voucher(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := convert[T](voucher.doc)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (f *salesVouchers[T]) RenderDocument(ctx context.Context, id string) error {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, f.kind.name+".RenderDocument", id); err != nil {
+		return err
+	}
+	_, err := f.render(id)
+	return err
+}
+
+func (f *salesVouchers[T]) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
+	return f.download(ctx, "DownloadDocument", id)
+}
+
+func (f *salesVouchers[T]) download(ctx context.Context, method, id string) (io.ReadCloser, error) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if err := f.c.record(ctx, f.kind.name+"."+method, id); err != nil {
+		return nil, err
+	}
+	fileID, err := f.render(id)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(f.c.files[fileID])), nil
+}
+
+// render stores the document of a voucher as a file and returns the file ID. f.c.mu must be held.
+func (f *salesVouchers[T]) render(id string) (string, error) {
+	voucher, err := f.voucher(id)
+	if err != nil {
+		return "", err
+	}
+	if f.kind != dunningKind && voucher.status() == types.VoucherStatusDraft {
+		return "", apiError(http.StatusNotAcceptable, "documents of draft vouchers can't be rendered")
+	}
+	files, _ := voucher.doc["files"].(map[string]interface{})
+	if fileID, ok := files["documentFileId"].(string); ok {
+		return fileID, nil
+	}
+	fileID := uuid.New()
+	f.c.files[fileID] = []byte(fmt.Sprintf("%%PDF-1.4\n%% %s %s %s\n%%%%EOF\n", f.kind.voucherType, voucher.string("voucherNumber"), id))
+	voucher.doc["files"] = map[string]interface{}{"documentFileId": fileID}
+	return fileID, nil
+}
+
+// toDocument returns the decoded JSON representation of v.
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	doc, err := convert[map[string]interface{}](v)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return doc, nil
+}
+
+// voucherDate returns the voucher date of a document as a date string, e.g. "2024-03-01".
+func voucherDate(doc map[string]interface{}) string {
+	switch v := doc["voucherDate"].(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.Format(time.DateOnly)
+		}
+		return v
+	case time.Time:
+		return v.Format(time.DateOnly)
+	}
+	return ""
+}
+
+type quotations struct {
+	*salesVouchers[types.Quotation]
+}
+
+func (f *quotations) Create(ctx context.Context, quotation *types.QuotationCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Create", "", quotation, finalize, quotation, finalize)
+}
+
+type invoices struct{ *salesVouchers[types.Invoice] }
+
+func (f *invoices) Create(ctx context.Context, invoice *types.InvoiceCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Create", "", invoice, finalize, invoice, finalize)
+}
+
+func (f *invoices) Pursue(ctx context.Context, precedingSalesVoucherID string, invoice *types.InvoiceCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Pursue", precedingSalesVoucherID, invoice, finalize, precedingSalesVoucherID, invoice, finalize)
+}
+
+func (f *invoices) DownloadFile(ctx context.Context, id string) (io.ReadCloser, error) {
+	return f.download(ctx, "DownloadFile", id)
+}
+
+type creditNotes struct {
+	*salesVouchers[types.CreditNote]
+}
+
+func (f *creditNotes) Create(ctx context.Context, creditNote *types.CreditNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Create", "", creditNote, finalize, creditNote, finalize)
+}
+
+func (f *creditNotes) Pursue(ctx context.Context, precedingSalesVoucherID string, creditNote *types.CreditNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Pursue", precedingSalesVoucherID, creditNote, finalize, precedingSalesVoucherID, creditNote, finalize)
+}
+
+type deliveryNotes struct {
+	*salesVouchers[types.DeliveryNote]
+}
+
+func (f *deliveryNotes) Create(ctx context.Context, deliveryNote *types.DeliveryNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Create", "", deliveryNote, finalize, deliveryNote, finalize)
+}
+
+func (f *deliveryNotes) Pursue(ctx context.Context, precedingSalesVoucherID string, deliveryNote *types.DeliveryNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Pursue", precedingSalesVoucherID, deliveryNote, finalize, precedingSalesVoucherID, deliveryNote, finalize)
+}
+
+type orderConfirmations struct {
+	*salesVouchers[types.OrderConfirmation]
+}
+
+func (f *orderConfirmations) Create(ctx context.Context, orderConfirmation *types.OrderConfirmation, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Create", "", orderConfirmation, finalize, orderConfirmation, finalize)
+}
+
+func (f *orderConfirmations) Pursue(ctx context.Context, precedingSalesVoucherID string, orderConfirmation *types.OrderConfirmation, finalize bool) (*types.ActionResult, error) {
+	return f.create(ctx, "Pursue", precedingSalesVoucherID, orderConfirmation, finalize, precedingSalesVoucherID, orderConfirmation, finalize)
+}
+
+type dunnings struct{ *salesVouchers[types.Dunning] }
+
+func (f *dunnings) Create(ctx context.Context, precedingSalesVoucherID string, dunning *types.DunningCreateRequest) (*types.ActionResult, error) {
+	return f.create(ctx, "Create", precedingSalesVoucherID, dunning, false, precedingSalesVoucherID, dunning)
+}
+
+func (f *dunnings) Pursue(ctx context.Context, precedingSalesVoucherID string, dunning *types.DunningCreateRequest) (*types.ActionResult, error) {
+	return f.create(ctx, "Pursue", precedingSalesVoucherID, dunning, false, precedingSalesVoucherID, dunning)
+}
+
+type downPaymentInvoices struct {
+	*salesVouchers[types.DownPaymentInvoice]
+}
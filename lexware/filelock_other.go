@@ -0,0 +1,21 @@
+//go:build !unix
+
+package lexware
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+var errFileLockingUnsupported = errors.New("lexware: file locking is not supported on this platform")
+
+func checkFileLocking() error {
+	return errFileLockingUnsupported
+}
+
+func lockFile(context.Context, *os.File) error {
+	return errFileLockingUnsupported
+}
+
+func unlockFile(*os.File) {}
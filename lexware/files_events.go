@@ -1,13 +1,10 @@
 package lexware
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/http"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -15,56 +12,30 @@ import (
 type filesClient struct{ client *Client }
 
 func (c *filesClient) Upload(ctx context.Context, filename string, content io.Reader, fileType types.FileUploadType) (*types.FileUploadResponse, error) {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-	part, err := writer.CreateFormFile("file", filename)
+	req, finish, err := newMultipartRequest(ctx, operation{name: "Files.Upload"}, "/v1/files", filename, content, [][2]string{{"type", string(fileType)}})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
-	}
-	if _, err := io.Copy(part, content); err != nil {
-		return nil, fmt.Errorf("failed to copy file content: %w", err)
-	}
-	if err := writer.WriteField("type", string(fileType)); err != nil {
-		return nil, fmt.Errorf("failed to write type field: %w", err)
-	}
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-	req, err := http.NewRequestWithContext(ctx, "POST", c.client.baseURL+"/v1/files", &buf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+c.client.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Accept", "application/json")
-	resp, err := c.client.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-	respBody, err := io.ReadAll(resp.Body)
+	defer finish()
+	body, err := c.client.do(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		return nil, err
 	}
 	var result types.FileUploadResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 	return &result, nil
 }
 
 func (c *filesClient) Download(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/files/"+id, nil, map[string]string{"Accept": "application/octet-stream"})
+	path, err := idPath("/v1/files", id)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "Files.Download", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/octet-stream"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
@@ -72,19 +43,15 @@ func (c *filesClient) Download(ctx context.Context, id string) (io.ReadCloser, e
 type eventSubscriptionsClient struct{ client *Client }
 
 func (c *eventSubscriptionsClient) Create(ctx context.Context, subscription *types.EventSubscriptionCreateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "POST", "/v1/event-subscriptions", subscription)
-	if err != nil {
-		return nil, err
-	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "EventSubscriptions.Create"}, "/v1/event-subscriptions", subscription)
 }
 
 func (c *eventSubscriptionsClient) Get(ctx context.Context, id string) (*types.EventSubscription, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/event-subscriptions/"+id, nil)
+	path, err := idPath("/v1/event-subscriptions", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "EventSubscriptions.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +63,7 @@ func (c *eventSubscriptionsClient) Get(ctx context.Context, id string) (*types.E
 }
 
 func (c *eventSubscriptionsClient) List(ctx context.Context) ([]types.EventSubscription, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/event-subscriptions", nil)
+	body, err := c.client.doRequest(ctx, operation{name: "EventSubscriptions.List"}, "GET", "/v1/event-subscriptions", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +77,10 @@ func (c *eventSubscriptionsClient) List(ctx context.Context) ([]types.EventSubsc
 }
 
 func (c *eventSubscriptionsClient) Delete(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "DELETE", "/v1/event-subscriptions/"+id, nil)
+	path, err := idPath("/v1/event-subscriptions", id)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "EventSubscriptions.Delete", resourceID: id}, "DELETE", path, nil)
 	return err
 }
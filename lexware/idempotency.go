@@ -0,0 +1,440 @@
+package lexware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// DefaultIdempotencyTTL is the default time idempotency keys are remembered.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyClockSkew is the difference between the local clock and the API's tolerated when
+// comparing the time a request was sent to the creation time of vouchers.
+const idempotencyClockSkew = time.Minute
+
+// ErrOutcomeUnknown is returned for requests with an idempotency key whose earlier attempt failed
+// without a definite answer, e.g. because of a timeout, and which can't be checked for a created
+// voucher, or where several vouchers match. It is also returned while another call sends the
+// request of the same key. Check the voucher manually before retrying with a new key.
+var ErrOutcomeUnknown = errors.New("lexware: outcome of an earlier request is unknown")
+
+// IdempotencyStore stores the state of idempotency keys. Implementations must be safe for
+// concurrent use; share one between processes, e.g. backed by Redis, to deduplicate requests
+// across them.
+type IdempotencyStore interface {
+	// Get returns the record of key, or nil if the key is unknown or expired.
+	Get(ctx context.Context, key string) (*IdempotencyRecord, error)
+	// Reserve atomically marks key as being sent before a request is sent with it. It returns the
+	// record of key before the call, or nil if the key is unknown or expired, and whether key was
+	// reserved:
+	//
+	//   - an unknown or expired key is stored with record for ttl and reserved,
+	//   - a pending record that isn't being sent gets Sending set, keeps its SentAt and is reserved,
+	//   - a record with a Result or with Sending set is left unchanged and not reserved.
+	Reserve(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (previous *IdempotencyRecord, reserved bool, err error)
+	// Set stores the record of key for ttl.
+	Set(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+}
+
+// IdempotencyRecord is the state of an idempotency key.
+type IdempotencyRecord struct {
+	// Result is the result of the successful request. It is nil while the outcome of the request
+	// is unknown.
+	Result *types.ActionResult `json:"result,omitempty"`
+	// SentAt is the time the request was sent first.
+	SentAt time.Time `json:"sentAt"`
+	// Sending is set while a call sends the request or checks for its outcome. If the process
+	// ends during that, the key stays reserved until it expires.
+	Sending bool `json:"sending,omitempty"`
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context that makes Create calls of all resources and Pursue calls
+// of sales vouchers made with it idempotent. A call with a key that already succeeded returns the
+// stored result without sending the request again.
+//
+// If the request fails without a definite answer (network errors, timeouts and 5xx responses),
+// the voucherlist is searched for a voucher matching the voucher type, contact and voucher date of
+// the request, created after it was sent, before it is sent again. The total gross amount is
+// compared too if the request sets it. A voucher found is fetched for its version and dates. Such
+// requests are never retried blindly, even with RetryPolicy.RetryNonIdempotent set. Requests
+// without a contact, and those for contacts, articles, event subscriptions and dunnings, which
+// are not listed in the voucherlist, return ErrOutcomeUnknown instead.
+//
+// Only one call sends the request of a key at a time; concurrent calls with the same key return
+// ErrOutcomeUnknown.
+//
+// Keys are scoped per operation, e.g. "Invoices.Create". Use a fresh key for every resource to
+// create, e.g. derived from the order it belongs to.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// voucherKinds maps the resources whose create requests can be found in the voucherlist to the
+// type and resource path of their vouchers. The type of bookkeeping vouchers is taken from the
+// request.
+var voucherKinds = map[string]struct {
+	voucherType types.VoucherType
+	collection  string
+}{
+	"CreditNotes":        {types.VoucherTypeCreditNote, "credit-notes"},
+	"DeliveryNotes":      {types.VoucherTypeDeliveryNote, "delivery-notes"},
+	"Invoices":           {types.VoucherTypeInvoice, "invoices"},
+	"OrderConfirmations": {types.VoucherTypeOrderConfirmation, "order-confirmations"},
+	"Quotations":         {types.VoucherTypeQuotation, "quotations"},
+	"Vouchers":           {"", "vouchers"},
+}
+
+// voucherMatch describes the voucher a create request results in, to find it in the voucherlist.
+type voucherMatch struct {
+	// resource is the name of the resource client, e.g. "Invoices".
+	resource    string
+	voucherType types.VoucherType
+	// collection is the path segment of the voucher's resource URI, e.g. "invoices".
+	collection  string
+	contactID   string
+	contactName string
+	// voucherDate is the voucher date as date string, e.g. "2024-03-01".
+	voucherDate string
+	// totalAmount is the gross amount of the voucher, or 0 if the API calculates it from the
+	// line items.
+	totalAmount float64
+}
+
+// newVoucherMatch returns the voucherMatch of a create request, or nil if the created resource
+// isn't listed in the voucherlist. The request body is read through its JSON representation,
+// which is shared by all sales voucher types and bookkeeping vouchers.
+func newVoucherMatch(op operation, body interface{}) *voucherMatch {
+	resource, _, _ := strings.Cut(op.name, ".")
+	kind, ok := voucherKinds[resource]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+	var fields struct {
+		Type             types.VoucherType `json:"type"`
+		VoucherDate      string            `json:"voucherDate"`
+		ContactID        string            `json:"contactId"`
+		TotalGrossAmount float64           `json:"totalGrossAmount"`
+		Address          *struct {
+			ContactID string `json:"contactId"`
+			Name      string `json:"name"`
+		} `json:"address"`
+		TotalPrice *struct {
+			TotalGrossAmount float64 `json:"totalGrossAmount"`
+		} `json:"totalPrice"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	m := &voucherMatch{
+		resource:    resource,
+		voucherType: kind.voucherType,
+		collection:  kind.collection,
+		contactID:   fields.ContactID,
+		voucherDate: fields.VoucherDate,
+		totalAmount: fields.TotalGrossAmount,
+	}
+	if m.voucherType == "" {
+		m.voucherType = fields.Type
+	}
+	if len(m.voucherDate) > len(time.DateOnly) {
+		m.voucherDate = m.voucherDate[:len(time.DateOnly)]
+	}
+	if fields.Address != nil {
+		m.contactID, m.contactName = fields.Address.ContactID, fields.Address.Name
+	}
+	if fields.TotalPrice != nil {
+		m.totalAmount = fields.TotalPrice.TotalGrossAmount
+	}
+	return m
+}
+
+// matches reports whether item may be the voucher created by a request sent at sentAt. Vouchers
+// created before then existed already.
+func (m *voucherMatch) matches(item types.VoucherListItem, sentAt time.Time) bool {
+	created, err := time.Parse(time.RFC3339, item.CreatedDate)
+	if err != nil || created.Before(sentAt.Add(-idempotencyClockSkew)) {
+		return false
+	}
+	if m.contactID == "" && m.contactName != "" && !strings.EqualFold(item.ContactName, m.contactName) {
+		return false
+	}
+	if m.voucherDate != "" && !strings.HasPrefix(item.VoucherDate, m.voucherDate) {
+		return false
+	}
+	return m.totalAmount == 0 || math.Abs(item.TotalAmount-m.totalAmount) < 0.005
+}
+
+// doCreate sends a POST request creating a resource. If ctx carries an idempotency key, the
+// request is deduplicated using the client's IdempotencyStore.
+func (c *Client) doCreate(ctx context.Context, op operation, path string, body interface{}) (_ *types.ActionResult, err error) {
+	key := idempotencyKeyFrom(ctx)
+	if key == "" || c.dryRun {
+		// Synthetic results of dry runs must not be returned for real requests later.
+		return c.sendCreate(ctx, op, path, body, false)
+	}
+	key = op.name + ":" + key
+	match := newVoucherMatch(op, body)
+	// The lookups and retries below share the deadline and the span of the call.
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	ctx, span := c.startCall(ctx, op)
+	defer func() { endCall(span, err) }()
+
+	record := &IdempotencyRecord{SentAt: time.Now(), Sending: true}
+	previous, reserved, err := c.idempotencyStore.Reserve(ctx, key, record, c.idempotencyTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if previous != nil && previous.Result != nil {
+		result := *previous.Result
+		return &result, nil
+	}
+	if !reserved {
+		return nil, fmt.Errorf("%w: %s is being sent by another call", ErrOutcomeUnknown, key)
+	}
+
+	// Unless the call ends with a definite answer, the key is left pending, so the next call
+	// checks for the voucher first. The store is updated even if ctx is done, as the key would
+	// stay reserved otherwise.
+	settled := false
+	defer func() {
+		if !settled {
+			_ = c.idempotencyStore.Set(context.WithoutCancel(ctx), key, &IdempotencyRecord{SentAt: record.SentAt}, c.idempotencyTTL)
+		}
+	}()
+	if previous != nil {
+		// An earlier call failed ambiguously.
+		record.SentAt = previous.SentAt
+		result, err := c.findCreated(ctx, key, record, match)
+		if err != nil || result != nil {
+			settled = result != nil
+			return result, err
+		}
+	}
+
+	policy := c.retryPolicyFor(ctx)
+	for attempt := 1; ; attempt++ {
+		result, err := c.sendCreate(ctx, op, path, body, true)
+		if err == nil {
+			// The voucher exists now; failing here would make callers create it again.
+			settled = true
+			_ = c.idempotencyStore.Set(context.WithoutCancel(ctx), key, &IdempotencyRecord{Result: result, SentAt: record.SentAt}, c.idempotencyTTL)
+			return result, nil
+		}
+		if !isAmbiguous(err) {
+			settled = true
+			_ = c.idempotencyStore.Delete(context.WithoutCancel(ctx), key)
+			return nil, err
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+		if err := sleepContext(ctx, policy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+		found, findErr := c.findCreated(ctx, key, record, match)
+		if findErr != nil || found != nil {
+			settled = found != nil
+			return found, findErr
+		}
+	}
+}
+
+// sendCreate sends a POST request and decodes the ActionResult. checked marks requests whose
+// ambiguous failures are checked by the caller; they are never retried blindly.
+func (c *Client) sendCreate(ctx context.Context, op operation, path string, body interface{}, checked bool) (*types.ActionResult, error) {
+	req, err := newJSONRequest(op, "POST", path, body, map[string]string{"Accept": "application/json", "Content-Type": "application/json"})
+	if err != nil {
+		return nil, err
+	}
+	req.checked = checked
+	respBody, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var result types.ActionResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &result, nil
+}
+
+// findCreated searches the voucherlist for the voucher created by an ambiguously failed request.
+// It returns nil if there is none, and stores the result of the key otherwise.
+func (c *Client) findCreated(ctx context.Context, key string, record *IdempotencyRecord, match *voucherMatch) (*types.ActionResult, error) {
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s can't be checked for a created voucher", ErrOutcomeUnknown, key)
+	}
+	if match.contactID == "" && match.contactName == "" {
+		// Any voucher of the day would match.
+		return nil, fmt.Errorf("%w: %s has no contact to find the created voucher by", ErrOutcomeUnknown, key)
+	}
+
+	filter := &types.VoucherListFilterOptions{
+		VoucherType:     match.voucherType,
+		ContactID:       match.contactID,
+		VoucherDateFrom: match.voucherDate,
+		VoucherDateTo:   match.voucherDate,
+		// The API filters by day, the exact time isn't known to both sides anyway.
+		CreatedDateFrom: record.SentAt.Format(time.DateOnly),
+	}
+	var found []types.VoucherListItem
+	for item, err := range c.voucherList.All(ctx, filter) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for created voucher: %w", err)
+		}
+		if match.matches(item, record.SentAt) {
+			found = append(found, item)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		result, err := c.getCreated(ctx, match, found[0].ID)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.idempotencyStore.Set(context.WithoutCancel(ctx), key, &IdempotencyRecord{Result: result, SentAt: record.SentAt}, c.idempotencyTTL)
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%w: %d vouchers match %s", ErrOutcomeUnknown, len(found), key)
+	}
+}
+
+// getCreated fetches the voucher found by findCreated for the fields of its ActionResult, which
+// the voucherlist lacks.
+func (c *Client) getCreated(ctx context.Context, match *voucherMatch, id string) (*types.ActionResult, error) {
+	path, err := idPath("/v1/"+match.collection, id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.doRequest(ctx, operation{name: match.resource + ".Get", resourceID: id}, "GET", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get created voucher: %w", err)
+	}
+	// Vouchers share the fields of an ActionResult except for the resource URI.
+	var result types.ActionResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	result.ResourceURI = c.baseURL + path
+	return &result, nil
+}
+
+// isAmbiguous reports whether a request failing with err may have been processed by the API.
+func isAmbiguous(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore. It is used by default.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyRecord
+	// swept is the time expired records were last removed. Keys are rarely read again, so they
+	// are not only removed by Get.
+	swept time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]memoryIdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) Get(_ context.Context, key string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(r.expires) {
+		delete(s.records, key)
+		return nil, nil
+	}
+	record := r.record
+	return &record, nil
+}
+
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	r, ok := s.records[key]
+	if !ok || now.After(r.expires) {
+		s.records[key] = memoryIdempotencyRecord{record: *record, expires: now.Add(ttl)}
+		return nil, true, nil
+	}
+	previous := r.record
+	if previous.Result != nil || previous.Sending {
+		return &previous, false, nil
+	}
+	r.record.Sending = true
+	r.expires = now.Add(ttl)
+	s.records[key] = r
+	return &previous, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Set(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	s.records[key] = memoryIdempotencyRecord{record: *record, expires: now.Add(ttl)}
+	return nil
+}
+
+// sweep removes expired records at most once a minute. s.mu must be held.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.swept) <= time.Minute {
+		return
+	}
+	for k, r := range s.records {
+		if now.After(r.expires) {
+			delete(s.records, k)
+		}
+	}
+	s.swept = now
+}
+
+func (s *MemoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
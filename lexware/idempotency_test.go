@@ -0,0 +1,281 @@
+package lexware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/lexwaretest"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// flakyTransport fails POST requests without a definite answer, either before or after they
+// reach the server.
+type flakyTransport struct {
+	mu sync.Mutex
+	// unsent and lost are the numbers of requests to fail before and after sending them.
+	unsent, lost int
+	posts        int
+	// sending, if set, receives a value when a POST request is sent, which then waits for release.
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	t.mu.Lock()
+	unsent := t.unsent > 0
+	if unsent {
+		t.unsent--
+	} else {
+		t.posts++
+	}
+	lost := !unsent && t.lost > 0
+	if lost {
+		t.lost--
+	}
+	t.mu.Unlock()
+	if unsent {
+		return nil, errors.New("connection refused")
+	}
+	if t.sending != nil {
+		t.sending <- struct{}{}
+		<-t.release
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || !lost {
+		return resp, err
+	}
+	resp.Body.Close()
+	return nil, errors.New("connection reset by peer")
+}
+
+func (t *flakyTransport) sent() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.posts
+}
+
+func newIdempotentClient(t *testing.T, transport *flakyTransport) (*lexware.Client, *lexwaretest.Server) {
+	t.Helper()
+	srv := lexwaretest.NewServer()
+	t.Cleanup(srv.Close)
+	config := srv.Config()
+	config.HTTPClient = &http.Client{Transport: transport}
+	config.Retry = lexware.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	client, err := lexware.NewClientWithConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, srv
+}
+
+func newInvoice(name string) *types.InvoiceCreateRequest {
+	return &types.InvoiceCreateRequest{
+		VoucherDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Address:     &types.Address{Name: name, CountryCode: "DE"},
+		LineItems: []types.LineItem{{
+			Type:      "custom",
+			Name:      "Consulting",
+			Quantity:  2,
+			UnitName:  "hours",
+			UnitPrice: &types.UnitPrice{Currency: "EUR", NetAmount: 100, TaxRatePercentage: 19},
+		}},
+		// The total gross amount is left to the API.
+		TotalPrice:    &types.TotalPrice{Currency: "EUR"},
+		TaxConditions: &types.TaxConditions{TaxType: "net"},
+	}
+}
+
+func invoiceCount(t *testing.T, client *lexware.Client) int {
+	t.Helper()
+	page, err := client.VoucherList().List(context.Background(), nil, &types.VoucherListFilterOptions{VoucherType: types.VoucherTypeInvoice})
+	if err != nil {
+		t.Fatalf("VoucherList.List returned %v", err)
+	}
+	return page.TotalElements
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	transport := &flakyTransport{}
+	client, _ := newIdempotentClient(t, transport)
+	ctx := lexware.WithIdempotencyKey(context.Background(), "order-1")
+
+	first, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+	if err != nil {
+		t.Fatalf("Create returned %v", err)
+	}
+	again, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+	if err != nil {
+		t.Fatalf("repeated Create returned %v", err)
+	}
+	if *again != *first {
+		t.Errorf("repeated Create returned %+v, want %+v", again, first)
+	}
+	if n := transport.sent(); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+
+	other := lexware.WithIdempotencyKey(context.Background(), "order-2")
+	if result, err := client.Invoices().Create(other, newInvoice("ACME GmbH"), false); err != nil || result.ID == first.ID {
+		t.Errorf("Create with another key returned %+v, %v", result, err)
+	}
+	if n := invoiceCount(t, client); n != 2 {
+		t.Errorf("got %d invoices, want 2", n)
+	}
+}
+
+func TestIdempotencyReservation(t *testing.T) {
+	transport := &flakyTransport{sending: make(chan struct{}), release: make(chan struct{})}
+	client, _ := newIdempotentClient(t, transport)
+	ctx := lexware.WithIdempotencyKey(context.Background(), "order-1")
+
+	done := make(chan error)
+	var first *types.ActionResult
+	go func() {
+		var err error
+		first, err = client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+		done <- err
+	}()
+	<-transport.sending
+	if _, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false); !errors.Is(err, lexware.ErrOutcomeUnknown) {
+		t.Errorf("concurrent Create returned %v, want ErrOutcomeUnknown", err)
+	}
+	close(transport.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Create returned %v", err)
+	}
+
+	transport.sending = nil
+	again, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+	if err != nil || again.ID != first.ID {
+		t.Errorf("Create after the first call returned %+v, %v, want %s", again, err, first.ID)
+	}
+	if n := invoiceCount(t, client); n != 1 {
+		t.Errorf("got %d invoices, want 1", n)
+	}
+}
+
+func TestIdempotencyRecovery(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		transport := &flakyTransport{lost: 1}
+		client, _ := newIdempotentClient(t, transport)
+		ctx := lexware.WithIdempotencyKey(context.Background(), "order-1")
+
+		result, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+		if err != nil {
+			t.Fatalf("Create returned %v", err)
+		}
+		if n := transport.sent(); n != 1 {
+			t.Errorf("sent %d requests, want 1", n)
+		}
+		invoice, err := client.Invoices().Get(context.Background(), result.ID)
+		if err != nil {
+			t.Fatalf("Get of the recovered invoice returned %v", err)
+		}
+		if result.Version != invoice.Version || result.CreatedDate.IsZero() || result.ResourceURI == "" {
+			t.Errorf("got result %+v, want version %d and the creation date", result, invoice.Version)
+		}
+
+		again, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+		if err != nil || *again != *result {
+			t.Errorf("repeated Create returned %+v, %v, want %+v", again, err, result)
+		}
+		if n := invoiceCount(t, client); n != 1 {
+			t.Errorf("got %d invoices, want 1", n)
+		}
+	})
+
+	t.Run("not created", func(t *testing.T) {
+		transport := &flakyTransport{unsent: 1}
+		client, _ := newIdempotentClient(t, transport)
+		ctx := lexware.WithIdempotencyKey(context.Background(), "order-1")
+
+		if _, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false); err != nil {
+			t.Fatalf("Create returned %v", err)
+		}
+		if n := invoiceCount(t, client); n != 1 {
+			t.Errorf("got %d invoices, want 1", n)
+		}
+	})
+
+	t.Run("other contacts", func(t *testing.T) {
+		transport := &flakyTransport{}
+		client, _ := newIdempotentClient(t, transport)
+		// Invoices of other contacts don't match.
+		if _, err := client.Invoices().Create(context.Background(), newInvoice("Globex AG"), false); err != nil {
+			t.Fatal(err)
+		}
+		transport.mu.Lock()
+		transport.lost = 1
+		transport.mu.Unlock()
+		ctx := lexware.WithIdempotencyKey(context.Background(), "order-1")
+
+		result, err := client.Invoices().Create(ctx, newInvoice("ACME GmbH"), false)
+		if err != nil {
+			t.Fatalf("Create returned %v", err)
+		}
+		if n := transport.sent(); n != 2 {
+			t.Errorf("sent %d requests, want 2", n)
+		}
+		if n := invoiceCount(t, client); n != 2 {
+			t.Errorf("got %d invoices, want 2", n)
+		}
+		if _, err := client.Invoices().Get(context.Background(), result.ID); err != nil {
+			t.Errorf("Get of the recovered invoice returned %v", err)
+		}
+	})
+
+	t.Run("no contact", func(t *testing.T) {
+		transport := &flakyTransport{lost: 1}
+		client, _ := newIdempotentClient(t, transport)
+		ctx := lexware.WithIdempotencyKey(context.Background(), "order-1")
+
+		invoice := newInvoice("")
+		invoice.Address = &types.Address{CountryCode: "DE"}
+		if _, err := client.Invoices().Create(ctx, invoice, false); !errors.Is(err, lexware.ErrOutcomeUnknown) {
+			t.Errorf("Create returned %v, want ErrOutcomeUnknown", err)
+		}
+		if n := transport.sent(); n != 1 {
+			t.Errorf("sent %d requests, want 1", n)
+		}
+	})
+}
+
+func TestIdempotencyContacts(t *testing.T) {
+	transport := &flakyTransport{}
+	client, _ := newIdempotentClient(t, transport)
+	ctx := lexware.WithIdempotencyKey(context.Background(), "customer-1")
+	contact := &types.ContactCreateRequest{
+		Roles:   &types.ContactRoles{Customer: &types.CustomerRole{}},
+		Company: &types.Company{Name: "ACME GmbH"},
+	}
+
+	first, err := client.Contacts().Create(ctx, contact)
+	if err != nil {
+		t.Fatalf("Create returned %v", err)
+	}
+	if again, err := client.Contacts().Create(ctx, contact); err != nil || *again != *first {
+		t.Errorf("repeated Create returned %+v, %v, want %+v", again, err, first)
+	}
+
+	// Contacts aren't listed in the voucherlist, so a lost response can't be checked.
+	transport.mu.Lock()
+	transport.lost = 1
+	transport.mu.Unlock()
+	lostCtx := lexware.WithIdempotencyKey(context.Background(), "customer-2")
+	for range 2 {
+		if _, err := client.Contacts().Create(lostCtx, contact); !errors.Is(err, lexware.ErrOutcomeUnknown) {
+			t.Errorf("Create after a lost response returned %v, want ErrOutcomeUnknown", err)
+		}
+	}
+	if n := transport.sent(); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
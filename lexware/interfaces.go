@@ -3,6 +3,7 @@ package lexware
 import (
 	"context"
 	"io"
+	"iter"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -14,6 +15,7 @@ type ArticlesInterface interface {
 	Update(ctx context.Context, id string, article *types.ArticleUpdateRequest) (*types.ActionResult, error)
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, opts *types.ListOptions, filter *types.ArticleFilterOptions) (*types.Page[types.Article], error)
+	All(ctx context.Context, filter *types.ArticleFilterOptions) iter.Seq2[types.Article, error]
 }
 
 // ContactsInterface provides methods for managing contacts.
@@ -22,6 +24,7 @@ type ContactsInterface interface {
 	Get(ctx context.Context, id string) (*types.Contact, error)
 	Update(ctx context.Context, id string, contact *types.ContactUpdateRequest) (*types.ActionResult, error)
 	List(ctx context.Context, opts *types.ListOptions, filter *types.ContactFilterOptions) (*types.Page[types.Contact], error)
+	All(ctx context.Context, filter *types.ContactFilterOptions) iter.Seq2[types.Contact, error]
 }
 
 // CountriesInterface provides methods for retrieving countries.
@@ -130,11 +133,13 @@ type QuotationsInterface interface {
 type RecurringTemplatesInterface interface {
 	Get(ctx context.Context, id string) (*types.RecurringTemplate, error)
 	List(ctx context.Context, opts *types.ListOptions) (*types.Page[types.RecurringTemplate], error)
+	All(ctx context.Context) iter.Seq2[types.RecurringTemplate, error]
 }
 
 // VoucherListInterface provides methods for retrieving the voucher list.
 type VoucherListInterface interface {
 	List(ctx context.Context, opts *types.ListOptions, filter *types.VoucherListFilterOptions) (*types.Page[types.VoucherListItem], error)
+	All(ctx context.Context, filter *types.VoucherListFilterOptions) iter.Seq2[types.VoucherListItem, error]
 }
 
 // VouchersInterface provides methods for managing vouchers.
@@ -143,5 +148,6 @@ type VouchersInterface interface {
 	Get(ctx context.Context, id string) (*types.Voucher, error)
 	Update(ctx context.Context, id string, voucher *types.VoucherUpdateRequest) (*types.ActionResult, error)
 	List(ctx context.Context, opts *types.ListOptions, filter *types.VoucherFilterOptions) (*types.Page[types.Voucher], error)
+	All(ctx context.Context, filter *types.VoucherFilterOptions) iter.Seq2[types.Voucher, error]
 	UploadFile(ctx context.Context, id string, filename string, content io.Reader) error
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -13,23 +12,19 @@ import (
 type invoicesClient struct{ client *Client }
 
 func (c *invoicesClient) Create(ctx context.Context, invoice *types.InvoiceCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/invoices"
-	if finalize {
-		path += "?finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, invoice)
+	path, err := createPath("/v1/invoices", finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "Invoices.Create"}, path, invoice)
 }
 
 func (c *invoicesClient) Get(ctx context.Context, id string) (*types.Invoice, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/invoices/"+id, nil)
+	path, err := idPath("/v1/invoices", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Invoices.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -41,30 +36,21 @@ func (c *invoicesClient) Get(ctx context.Context, id string) (*types.Invoice, er
 }
 
 func (c *invoicesClient) Pursue(ctx context.Context, precedingSalesVoucherID string, invoice *types.InvoiceCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/invoices?precedingSalesVoucherId=" + precedingSalesVoucherID
-	if finalize {
-		path += "&finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, invoice)
+	path, err := pursuePath("/v1/invoices", precedingSalesVoucherID, finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "Invoices.Pursue", resourceID: precedingSalesVoucherID}, path, invoice)
 }
 
 func (c *invoicesClient) DownloadFile(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/invoices/"+id+"/file", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/invoices", id, "file")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "Invoices.DownloadFile", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
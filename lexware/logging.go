@@ -0,0 +1,223 @@
+package lexware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultRedactFields lists the JSON fields and query parameters whose values are redacted from
+// logged requests and responses if Config.RedactFields is not set. Apart from email addresses and
+// IBANs, personal data such as phone numbers is only redacted in these fields.
+var DefaultRedactFields = []string{
+	"email",
+	"emailAddress",
+	"emailAddresses",
+	"phoneNumber",
+	"phoneNumbers",
+	"iban",
+	"bic",
+	"accountHolder",
+	"taxNumber",
+	"vatRegistrationId",
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ibanPattern  = regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`)
+)
+
+// redactor removes secrets and personal data from logged values.
+type redactor struct {
+	fields map[string]bool
+}
+
+func newRedactor(fields []string) *redactor {
+	if fields == nil {
+		fields = DefaultRedactFields
+	}
+	r := &redactor{fields: make(map[string]bool, len(fields))}
+	for _, f := range fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	return r
+}
+
+// string redacts email addresses and IBAN-like strings wherever they appear in s.
+func (r *redactor) string(s string) string {
+	s = emailPattern.ReplaceAllString(s, redacted)
+	return ibanPattern.ReplaceAllString(s, redacted)
+}
+
+// url redacts the configured query parameters and PII found in the remaining ones.
+func (r *redactor) url(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	return u.Path + "?" + r.query(u.Query())
+}
+
+// query redacts the configured parameters and PII in all other values of an encoded query.
+func (r *redactor) query(query url.Values) string {
+	for key, values := range query {
+		for i, v := range values {
+			if r.fields[strings.ToLower(key)] {
+				values[i] = redacted
+			} else {
+				values[i] = r.string(v)
+			}
+		}
+	}
+	return query.Encode()
+}
+
+// json redacts the configured fields of a JSON document and PII found in all other strings.
+// Bodies that are not valid JSON are redacted as plain strings.
+func (r *redactor) json(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return r.string(string(body))
+	}
+	redactedBody, err := json.Marshal(r.value(v))
+	if err != nil {
+		return redacted
+	}
+	return string(redactedBody)
+}
+
+func (r *redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = redactAll(value)
+			} else {
+				v[key] = r.value(value)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.value(value)
+		}
+		return v
+	case string:
+		return r.string(v)
+	}
+	return v
+}
+
+// redactAll replaces all strings within v, keeping its structure so that it still decodes.
+func redactAll(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = redactAll(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactAll(value)
+		}
+		return v
+	case string:
+		return redacted
+	}
+	return v
+}
+
+// RedactJSON redacts fields of a JSON document and email addresses and IBAN-like strings found
+// anywhere else, as done for logged bodies. The structure of the document is kept, so redacted
+// responses still decode. If fields is nil, DefaultRedactFields is used.
+func RedactJSON(body []byte, fields []string) []byte {
+	return []byte(newRedactor(fields).json(body))
+}
+
+// RedactURL returns the path and query of u with the query parameters named in fields and email
+// addresses and IBAN-like strings redacted. The query parameters are sorted by name. If fields is
+// nil, DefaultRedactFields is used.
+func RedactURL(u *url.URL, fields []string) string {
+	return newRedactor(fields).url(u)
+}
+
+// loggingMiddleware logs every attempt with method, path, status and duration. At debug level,
+// JSON request and response bodies are logged as well. The Authorization header is never logged
+// and personal data is redacted.
+func loggingMiddleware(logger *slog.Logger, r *redactor) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*http.Response, error) {
+			ctx := req.Context()
+			debug := logger.Enabled(ctx, slog.LevelDebug)
+
+			attrs := []slog.Attr{
+				slog.String("operation", req.Operation),
+				slog.Int("attempt", req.Attempt),
+				slog.String("method", req.HTTPRequest.Method),
+				slog.String("path", r.url(req.HTTPRequest.URL)),
+			}
+			if req.ResourceID != "" {
+				attrs = append(attrs, slog.String("resource_id", req.ResourceID))
+			}
+			if debug {
+				if body, ok := requestBody(req.HTTPRequest); ok {
+					attrs = append(attrs, slog.String("request_body", r.json(body)))
+				}
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+
+			if resp != nil {
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+				if debug {
+					if body, ok := responseBody(resp); ok {
+						attrs = append(attrs, slog.String("response_body", r.json(body)))
+					}
+				}
+			}
+
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelWarn
+				attrs = append(attrs, slog.String("error", r.string(err.Error())))
+			}
+			logger.LogAttrs(ctx, level, "lexware request", attrs...)
+			return resp, err
+		}
+	}
+}
+
+// requestBody returns a copy of a replayable request body. Streamed uploads are not returned.
+func requestBody(req *http.Request) ([]byte, bool) {
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	return b, err == nil
+}
+
+// responseBody reads a JSON response body and replaces it with a copy, so it can still be read
+// by the caller. Other bodies, e.g. document downloads, are left untouched.
+func responseBody(resp *http.Response) ([]byte, bool) {
+	if !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return nil, false
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	return b, err == nil
+}
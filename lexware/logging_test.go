@@ -0,0 +1,117 @@
+package lexware
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+		want   string
+	}{
+		{
+			name: "nested fields",
+			body: `{"company":{"name":"ACME GmbH","taxNumber":"12/345/67890"},"version":0}`,
+			want: `{"company":{"name":"ACME GmbH","taxNumber":"[REDACTED]"},"version":0}`,
+		},
+		{
+			name: "field holding an object",
+			body: `{"emailAddresses":{"business":["info@acme.de"],"private":["jane@example.com"]}}`,
+			want: `{"emailAddresses":{"business":["[REDACTED]"],"private":["[REDACTED]"]}}`,
+		},
+		{
+			name: "arrays of objects",
+			body: `[{"phoneNumbers":{"office":["+49 221 123456"]}},{"iban":"DE02120300000000202051","amount":12.5}]`,
+			want: `[{"phoneNumbers":{"office":["[REDACTED]"]}},{"amount":12.5,"iban":"[REDACTED]"}]`,
+		},
+		{
+			name: "field names are case insensitive",
+			body: `{"IBAN":"DE02120300000000202051","Email":"jane@example.com"}`,
+			want: `{"Email":"[REDACTED]","IBAN":"[REDACTED]"}`,
+		},
+		{
+			name: "personal data in free text",
+			body: `{"remark":"Contact jane@example.com, pay to DE02 1203 0000 0000 2020 51"}`,
+			want: `{"remark":"Contact [REDACTED], pay to [REDACTED]"}`,
+		},
+		{
+			name: "phone numbers in free text are kept",
+			body: `{"remark":"Call +49 221 123456"}`,
+			want: `{"remark":"Call +49 221 123456"}`,
+		},
+		{
+			name:   "custom fields",
+			body:   `{"name":"Jane Doe","street":"Hauptstr. 1","taxNumber":"12/345/67890"}`,
+			fields: []string{"name", "street"},
+			want:   `{"name":"[REDACTED]","street":"[REDACTED]","taxNumber":"12/345/67890"}`,
+		},
+		{
+			name:   "no fields",
+			body:   `{"email":"jane@example.com","phoneNumber":"+49 221 123456"}`,
+			fields: []string{},
+			want:   `{"email":"[REDACTED]","phoneNumber":"+49 221 123456"}`,
+		},
+		{
+			name: "invalid JSON",
+			body: `email=jane@example.com&iban=DE02120300000000202051`,
+			want: `email=[REDACTED]&iban=[REDACTED]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactJSON([]byte(tt.body), tt.fields)); got != tt.want {
+				t.Errorf("RedactJSON(%s)\n got %s\nwant %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		fields []string
+		want   string
+	}{
+		{
+			name: "no query",
+			url:  "https://api.lexware.io/v1/contacts/123",
+			want: "/v1/contacts/123",
+		},
+		{
+			name: "redacted parameters",
+			url:  "https://api.lexware.io/v1/contacts?page=0&email=jane%40example.com&name=ACME",
+			want: "/v1/contacts?email=%5BREDACTED%5D&name=ACME&page=0",
+		},
+		{
+			name: "repeated parameters",
+			url:  "https://api.lexware.io/v1/contacts?email=a%40example.com&email=b%40example.com",
+			want: "/v1/contacts?email=%5BREDACTED%5D&email=%5BREDACTED%5D",
+		},
+		{
+			name: "personal data in other parameters",
+			url:  "https://api.lexware.io/v1/voucherlist?remark=jane%40example.com+DE02120300000000202051",
+			want: "/v1/voucherlist?remark=%5BREDACTED%5D+%5BREDACTED%5D",
+		},
+		{
+			name:   "custom fields",
+			url:    "https://api.lexware.io/v1/contacts?name=ACME&number=10000&email=jane%40example.com",
+			fields: []string{"Name"},
+			want:   "/v1/contacts?email=%5BREDACTED%5D&name=%5BREDACTED%5D&number=10000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := RedactURL(u, tt.fields); got != tt.want {
+				t.Errorf("RedactURL(%s)\n got %s\nwant %s", tt.url, got, tt.want)
+			}
+		})
+	}
+}
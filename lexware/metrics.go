@@ -0,0 +1,23 @@
+package lexware
+
+import "time"
+
+// Metrics records measurements of the requests sent by a client. Implementations must be safe
+// for concurrent use. The operation is the interface method that issued the request, e.g.
+// "VoucherList.List". See the prommetrics package for a Prometheus implementation.
+type Metrics interface {
+	// ObserveRequest is called after every attempt of a request. statusCode is 0 if the request
+	// failed without a response.
+	ObserveRequest(operation string, statusCode int, duration time.Duration)
+	// ObserveRetry is called before a failed attempt is retried.
+	ObserveRetry(operation string, statusCode int)
+	// ObserveRateLimitWait is called before every attempt with the time it was blocked by the
+	// rate limiter.
+	ObserveRateLimitWait(operation string, wait time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, int, time.Duration)  {}
+func (noopMetrics) ObserveRetry(string, int)                   {}
+func (noopMetrics) ObserveRateLimitWait(string, time.Duration) {}
@@ -0,0 +1,69 @@
+package lexware
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request describes a single attempt of an API call as seen by middleware.
+type Request struct {
+	// Operation is the interface method that issued the request, e.g. "Invoices.Create".
+	Operation string
+	// ResourceID is the ID of the resource the operation works on. It is empty for list and
+	// create operations; for Pursue it is the ID of the preceding sales voucher.
+	ResourceID string
+	// Attempt is the number of the attempt, starting at 1. Retried requests pass the middleware
+	// chain again.
+	Attempt int
+	// HTTPRequest is the outgoing request. Middleware may modify its headers.
+	HTTPRequest *http.Request
+}
+
+// Context returns the context of the request.
+func (r *Request) Context() context.Context {
+	return r.HTTPRequest.Context()
+}
+
+// Handler sends a single attempt of an API request.
+//
+// For non-2xx responses a Handler returns both the response and an *APIError. The body of such a
+// response has already been read and can be read again. For successful responses the body is
+// untouched, which matters for document downloads: middleware must not consume it.
+type Handler func(req *Request) (*http.Response, error)
+
+// Middleware wraps a Handler to add behavior to every request, e.g. logging or header injection.
+// Middleware configured in Config.Middleware applies to JSON calls, document downloads and
+// multipart uploads alike. The first middleware is the outermost one.
+type Middleware func(next Handler) Handler
+
+// InterceptRequest returns middleware that calls fn before every attempt is sent.
+// If fn returns an error, the request is aborted with that error and not retried.
+func InterceptRequest(fn func(req *Request) error) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*http.Response, error) {
+			if err := fn(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// InterceptResponse returns middleware that calls fn after every attempt. resp is nil if the
+// request failed without a response; for non-2xx responses err is the decoded *APIError.
+func InterceptResponse(fn func(req *Request, resp *http.Response, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*http.Response, error) {
+			resp, err := next(req)
+			fn(req, resp, err)
+			return resp, err
+		}
+	}
+}
+
+func chainMiddleware(h Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
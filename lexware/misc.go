@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -11,7 +12,7 @@ import (
 type countriesClient struct{ client *Client }
 
 func (c *countriesClient) List(ctx context.Context) ([]types.Country, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/countries", nil)
+	body, err := c.client.doCachedRequest(ctx, operation{name: "Countries.List"}, "/v1/countries")
 	if err != nil {
 		return nil, err
 	}
@@ -25,7 +26,7 @@ func (c *countriesClient) List(ctx context.Context) ([]types.Country, error) {
 type profileClient struct{ client *Client }
 
 func (c *profileClient) Get(ctx context.Context) (*types.Profile, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/profile", nil)
+	body, err := c.client.doCachedRequest(ctx, operation{name: "Profile.Get"}, "/v1/profile")
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +40,11 @@ func (c *profileClient) Get(ctx context.Context) (*types.Profile, error) {
 type paymentsClient struct{ client *Client }
 
 func (c *paymentsClient) Get(ctx context.Context, id string) (*types.Payment, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/payments/"+id, nil)
+	path, err := idPath("/v1/payments", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Payments.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +58,7 @@ func (c *paymentsClient) Get(ctx context.Context, id string) (*types.Payment, er
 type paymentConditionsClient struct{ client *Client }
 
 func (c *paymentConditionsClient) List(ctx context.Context) ([]types.PaymentCondition, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/payment-conditions", nil)
+	body, err := c.client.doCachedRequest(ctx, operation{name: "PaymentConditions.List"}, "/v1/payment-conditions")
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +72,7 @@ func (c *paymentConditionsClient) List(ctx context.Context) ([]types.PaymentCond
 type postingCategoriesClient struct{ client *Client }
 
 func (c *postingCategoriesClient) List(ctx context.Context) ([]types.PostingCategory, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/posting-categories", nil)
+	body, err := c.client.doCachedRequest(ctx, operation{name: "PostingCategories.List"}, "/v1/posting-categories")
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +86,7 @@ func (c *postingCategoriesClient) List(ctx context.Context) ([]types.PostingCate
 type printLayoutsClient struct{ client *Client }
 
 func (c *printLayoutsClient) List(ctx context.Context) ([]types.PrintLayout, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/print-layouts", nil)
+	body, err := c.client.doCachedRequest(ctx, operation{name: "PrintLayouts.List"}, "/v1/print-layouts")
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +100,11 @@ func (c *printLayoutsClient) List(ctx context.Context) ([]types.PrintLayout, err
 type recurringTemplatesClient struct{ client *Client }
 
 func (c *recurringTemplatesClient) Get(ctx context.Context, id string) (*types.RecurringTemplate, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/recurring-templates/"+id, nil)
+	path, err := idPath("/v1/recurring-templates", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "RecurringTemplates.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -108,8 +117,10 @@ func (c *recurringTemplatesClient) Get(ctx context.Context, id string) (*types.R
 
 func (c *recurringTemplatesClient) List(ctx context.Context, opts *types.ListOptions) (*types.Page[types.RecurringTemplate], error) {
 	params := make(map[string]string)
-	addPagination(params, opts)
-	body, err := c.client.doRequest(ctx, "GET", "/v1/recurring-templates"+buildQueryString(params), nil)
+	if err := addPagination(params, opts, "/v1/recurring-templates"); err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, listOperation("RecurringTemplates.List", opts), "GET", "/v1/recurring-templates"+buildQueryString(params), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -119,3 +130,9 @@ func (c *recurringTemplatesClient) List(ctx context.Context, opts *types.ListOpt
 	}
 	return &page, nil
 }
+
+func (c *recurringTemplatesClient) All(ctx context.Context) iter.Seq2[types.RecurringTemplate, error] {
+	return traceAll(c.client, ctx, "RecurringTemplates.All", func(ctx context.Context) iter.Seq2[types.RecurringTemplate, error] {
+		return ListAll(ctx, c.List, func(t types.RecurringTemplate) string { return t.ID })
+	})
+}
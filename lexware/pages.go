@@ -0,0 +1,128 @@
+package lexware
+
+import (
+	"context"
+	"iter"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// MaxPageSize is the largest page size the Lexware API accepts. Iterators fetch pages of this size.
+const MaxPageSize = 250
+
+// ListAll returns an iterator over the items of all pages returned by list, starting at the first
+// page. It backs the All methods of the resource clients and may be used for custom implementations
+// of them.
+//
+// Pages are fetched as the iteration proceeds, or ahead of it with the Prefetch call option, and
+// sorted as set with the Sort call option. When items shift between pages because records are
+// created during the iteration, items already yielded are skipped by their id; items with an
+// empty id are always yielded. Records deleted during the iteration may shift others onto pages
+// already fetched, which are then missed.
+//
+// An error ends the iteration after it is yielded, including the context's error once ctx is done.
+func ListAll[T any](ctx context.Context, list func(ctx context.Context, opts *types.ListOptions) (*types.Page[T], error), id func(T) string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		// All pages share the deadline of the call.
+		ctx, cancelTimeout := withTimeout(ctx)
+		defer cancelTimeout()
+		// Stops the prefetching of pages that aren't needed anymore.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		o := callOptionsFrom(ctx)
+		pages := &pager[T]{ctx: ctx, list: list, ahead: o.prefetch, sort: o.sort, direction: o.direction}
+
+		var zero T
+		seen := make(map[string]struct{})
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			result, err := pages.next()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range result.Content {
+				if key := id(item); key != "" {
+					if _, ok := seen[key]; ok {
+						continue
+					}
+					seen[key] = struct{}{}
+				}
+				if !yield(item, nil) {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					yield(zero, err)
+					return
+				}
+			}
+			if result.Last || len(result.Content) == 0 {
+				return
+			}
+		}
+	}
+}
+
+type pageResult[T any] struct {
+	page *types.Page[T]
+	err  error
+}
+
+// pager fetches the pages of a list in order. If ahead is positive, up to ahead pages after the
+// one returned last are fetched concurrently, but only pages known to exist.
+type pager[T any] struct {
+	ctx   context.Context
+	list  func(ctx context.Context, opts *types.ListOptions) (*types.Page[T], error)
+	ahead int
+	// sort and direction are sent with every page.
+	sort      types.SortField
+	direction types.SortDirection
+
+	// page is the number of the next page returned, pending holds the pages fetched ahead of it.
+	page    int
+	pending []chan pageResult[T]
+	// total is the largest number of pages reported so far.
+	total int
+}
+
+// next returns the next page.
+func (p *pager[T]) next() (*types.Page[T], error) {
+	var result pageResult[T]
+	if len(p.pending) > 0 {
+		result = <-p.pending[0]
+		p.pending = p.pending[1:]
+	} else {
+		result.page, result.err = p.list(p.ctx, p.options(p.page))
+	}
+	p.page++
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	p.total = max(p.total, result.page.TotalPages)
+	for len(p.pending) < p.ahead {
+		page := p.page + len(p.pending)
+		if page >= p.total {
+			break
+		}
+		p.pending = append(p.pending, p.fetch(page))
+	}
+	return result.page, nil
+}
+
+func (p *pager[T]) fetch(page int) chan pageResult[T] {
+	// Buffered, so fetches of pages that are never consumed don't block.
+	ch := make(chan pageResult[T], 1)
+	go func() {
+		result, err := p.list(p.ctx, p.options(page))
+		ch <- pageResult[T]{page: result, err: err}
+	}()
+	return ch
+}
+
+func (p *pager[T]) options(page int) *types.ListOptions {
+	return &types.ListOptions{Page: page, Size: MaxPageSize, Sort: p.sort, Direction: p.direction}
+}
@@ -0,0 +1,215 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// pagedList returns a list function serving pages of 2 items each, "0-0", "0-1", "1-0" and so
+// on. Later pages are answered faster, so prefetched pages arrive out of order.
+func pagedList(pages int) (list func(ctx context.Context, opts *types.ListOptions) (*types.Page[string], error), requested func() []int) {
+	var mu sync.Mutex
+	var calls []int
+	list = func(ctx context.Context, opts *types.ListOptions) (*types.Page[string], error) {
+		mu.Lock()
+		calls = append(calls, opts.Page)
+		mu.Unlock()
+		if opts.Size != MaxPageSize {
+			return nil, fmt.Errorf("page size %d, want %d", opts.Size, MaxPageSize)
+		}
+		select {
+		case <-time.After(time.Duration(pages-opts.Page) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &types.Page[string]{
+			Content:    []string{fmt.Sprintf("%d-0", opts.Page), fmt.Sprintf("%d-1", opts.Page)},
+			Number:     opts.Page,
+			TotalPages: pages,
+			Last:       opts.Page == pages-1,
+		}, nil
+	}
+	requested = func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		got := slices.Clone(calls)
+		slices.Sort(got)
+		return got
+	}
+	return list, requested
+}
+
+func TestListAllStopsEarly(t *testing.T) {
+	list, _ := pagedList(10)
+	ctx := WithCallOptions(context.Background(), Prefetch(4))
+
+	var got []string
+	for item, err := range ListAll(ctx, list, func(s string) string { return s }) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, item)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if want := []string{"0-0", "0-1", "1-0"}; !slices.Equal(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+}
+
+func TestListAllError(t *testing.T) {
+	errList := errors.New("list failed")
+	list, _ := pagedList(4)
+	failing := func(ctx context.Context, opts *types.ListOptions) (*types.Page[string], error) {
+		if opts.Page == 2 {
+			return nil, errList
+		}
+		return list(ctx, opts)
+	}
+	ctx := WithCallOptions(context.Background(), Prefetch(2))
+
+	var got []string
+	var gotErr error
+	for item, err := range ListAll(ctx, failing, func(s string) string { return s }) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		got = append(got, item)
+	}
+	if want := []string{"0-0", "0-1", "1-0", "1-1"}; !slices.Equal(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+	if !errors.Is(gotErr, errList) {
+		t.Errorf("error = %v, want %v", gotErr, errList)
+	}
+}
+
+func TestListAllSkipsShiftedItems(t *testing.T) {
+	// A record created during the iteration shifts "b" onto the second page.
+	pages := [][]string{{"a", "b"}, {"b", "c"}}
+	list := func(_ context.Context, opts *types.ListOptions) (*types.Page[string], error) {
+		return &types.Page[string]{Content: pages[opts.Page], TotalPages: len(pages), Last: opts.Page == len(pages)-1}, nil
+	}
+
+	var got []string
+	for item, err := range ListAll(context.Background(), list, func(s string) string { return s }) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, item)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+}
+
+func TestListAllSort(t *testing.T) {
+	ctx := WithCallOptions(context.Background(), Sort(types.SortByVoucherDate, types.SortDesc))
+	list := func(_ context.Context, opts *types.ListOptions) (*types.Page[string], error) {
+		if opts.Sort != types.SortByVoucherDate || opts.Direction != types.SortDesc {
+			return nil, fmt.Errorf("sorted by %q %q", opts.Sort, opts.Direction)
+		}
+		return &types.Page[string]{Content: []string{"a"}, TotalPages: 1, Last: true}, nil
+	}
+	for _, err := range ListAll(ctx, list, func(s string) string { return s }) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListAllPrefetch(t *testing.T) {
+	const pages = 6
+	var want []string
+	for page := range pages {
+		want = append(want, fmt.Sprintf("%d-0", page), fmt.Sprintf("%d-1", page))
+	}
+
+	for _, prefetch := range []int{0, 1, 3, pages, 2 * pages} {
+		t.Run(fmt.Sprintf("prefetch %d", prefetch), func(t *testing.T) {
+			list, requested := pagedList(pages)
+			ctx := WithCallOptions(context.Background(), Prefetch(prefetch))
+
+			var got []string
+			for item, err := range ListAll(ctx, list, func(s string) string { return s }) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got = append(got, item)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("items = %v, want %v", got, want)
+			}
+			// Every page is fetched once, and none beyond the last.
+			if calls := requested(); !slices.Equal(calls, []int{0, 1, 2, 3, 4, 5}) {
+				t.Errorf("requested pages %v, want each of 0 to 5 once", calls)
+			}
+		})
+	}
+}
+
+func TestListAllTimeout(t *testing.T) {
+	list := func(ctx context.Context, opts *types.ListOptions) (*types.Page[string], error) {
+		if timeout := callOptionsFrom(ctx).timeout; timeout != 0 {
+			return nil, fmt.Errorf("page %d was requested with its own timeout of %v", opts.Page, timeout)
+		}
+		select {
+		case <-time.After(20 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &types.Page[string]{Content: []string{fmt.Sprint(opts.Page)}, Number: opts.Page, TotalPages: 10, Last: opts.Page == 9}, nil
+	}
+
+	// Every page is fetched well within the timeout, all of them are not.
+	ctx := WithCallOptions(context.Background(), Timeout(50*time.Millisecond))
+	var items []string
+	var err error
+	for item, itemErr := range ListAll(ctx, list, func(item string) string { return item }) {
+		if itemErr != nil {
+			err = itemErr
+			break
+		}
+		items = append(items, item)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v after %d items, want context.DeadlineExceeded", err, len(items))
+	}
+	if len(items) >= 10 {
+		t.Errorf("got all %d items despite the timeout", len(items))
+	}
+}
+
+func TestAddPaginationInvalidSort(t *testing.T) {
+	for _, opts := range []*types.ListOptions{
+		{Sort: types.SortByName},
+		{Direction: types.SortDesc},
+		{Sort: types.SortByVoucherDate, Direction: "UP"},
+	} {
+		err := addPagination(map[string]string{}, opts, "/v1/voucherlist")
+		if !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("addPagination(%+v) = %v, want ErrInvalidSort", opts, err)
+		}
+		// Only errors of the API match ErrValidation.
+		if errors.Is(err, ErrValidation) {
+			t.Errorf("addPagination(%+v) = %v, which matches ErrValidation", opts, err)
+		}
+	}
+
+	params := map[string]string{}
+	if err := addPagination(params, &types.ListOptions{Page: 2, Size: 50, Sort: types.SortByName}, "/v1/contacts"); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"page": "2", "size": "50", "sort": "name,ASC"}; fmt.Sprint(params) != fmt.Sprint(want) {
+		t.Errorf("got params %v, want %v", params, want)
+	}
+}
@@ -0,0 +1,136 @@
+package lexware
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/rasche-thalhofer/lexware-go/internal/uuid"
+)
+
+// ErrInvalidID is matched via errors.Is by the *InvalidIDError returned when a resource ID isn't
+// a UUID. No request is sent in that case.
+var ErrInvalidID = errors.New("lexware: invalid resource ID")
+
+// InvalidIDError reports a resource ID that isn't a UUID, e.g. an empty one.
+type InvalidIDError struct {
+	// Param is the name of the offending parameter, e.g. "id" or "precedingSalesVoucherID".
+	Param string
+	ID    string
+}
+
+func (e *InvalidIDError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("lexware: missing %s", e.Param)
+	}
+	return fmt.Sprintf("lexware: invalid %s %q: must be a UUID", e.Param, e.ID)
+}
+
+// Is makes errors.Is(err, ErrInvalidID) work.
+func (e *InvalidIDError) Is(target error) bool {
+	return target == ErrInvalidID
+}
+
+// pathBuilder builds request paths from a fixed base and validated, escaped components:
+//
+//	path, err := newPath("/v1/invoices").id("id", id).segment("file").build()
+//
+// The first invalid ID is reported by build.
+type pathBuilder struct {
+	path  strings.Builder
+	query [][2]string
+	err   error
+}
+
+// newPath starts a path at base, a constant like "/v1/invoices" which is used unescaped.
+func newPath(base string) *pathBuilder {
+	p := &pathBuilder{}
+	p.path.WriteString(base)
+	return p
+}
+
+// id appends a validated resource ID as a path segment. param names the ID in errors.
+func (p *pathBuilder) id(param, id string) *pathBuilder {
+	if p.checkID(param, id) {
+		p.segment(id)
+	}
+	return p
+}
+
+// segment appends an escaped path segment.
+func (p *pathBuilder) segment(s string) *pathBuilder {
+	p.path.WriteByte('/')
+	p.path.WriteString(url.PathEscape(s))
+	return p
+}
+
+// queryID appends a validated resource ID as a query parameter.
+func (p *pathBuilder) queryID(key, param, id string) *pathBuilder {
+	if p.checkID(param, id) {
+		p.param(key, id)
+	}
+	return p
+}
+
+// param appends a query parameter. Parameters keep the order they were added in.
+func (p *pathBuilder) param(key, value string) *pathBuilder {
+	p.query = append(p.query, [2]string{key, value})
+	return p
+}
+
+func (p *pathBuilder) checkID(param, id string) bool {
+	if p.err != nil {
+		return false
+	}
+	if !uuid.Valid(id) {
+		p.err = &InvalidIDError{Param: param, ID: id}
+		return false
+	}
+	return true
+}
+
+// build returns the path, or an *InvalidIDError if an ID was invalid.
+func (p *pathBuilder) build() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	path := p.path.String()
+	for i, kv := range p.query {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		path += sep + url.QueryEscape(kv[0]) + "=" + url.QueryEscape(kv[1])
+	}
+	return path, nil
+}
+
+// idPath returns base followed by a validated resource ID and the given segments, the common
+// shape of resource paths.
+func idPath(base, id string, segments ...string) (string, error) {
+	p := newPath(base).id("id", id)
+	for _, s := range segments {
+		p.segment(s)
+	}
+	return p.build()
+}
+
+// createPath returns the path creating a sales voucher at base.
+func createPath(base string, finalize bool) (string, error) {
+	p := newPath(base)
+	if finalize {
+		p.param("finalize", "true")
+	}
+	return p.build()
+}
+
+// pursuePath returns the path creating a sales voucher at base that pursues the sales voucher
+// precedingSalesVoucherID.
+func pursuePath(base, precedingSalesVoucherID string, finalize bool) (string, error) {
+	p := newPath(base).queryID("precedingSalesVoucherId", "precedingSalesVoucherID", precedingSalesVoucherID)
+	if finalize {
+		p.param("finalize", "true")
+	}
+	return p.build()
+}
@@ -0,0 +1,162 @@
+package lexware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// DefaultIdleTimeout is the time after which unused clients are evicted from a ClientPool.
+const DefaultIdleTimeout = 30 * time.Minute
+
+// PoolConfig holds configuration options for a ClientPool.
+type PoolConfig struct {
+	// Config returns the client configuration for an organization, typically with the API key
+	// the organization granted. It is called whenever the pool creates a client. Required.
+	Config func(ctx context.Context, organizationID string) (Config, error)
+	// IdleTimeout is the time after which an unused client is evicted. Defaults to DefaultIdleTimeout.
+	IdleTimeout time.Duration
+}
+
+// ClientPool lazily creates and caches one Client per Lexware organization.
+//
+// Every organization keeps its own rate limiter, which survives when its client is rebuilt. A
+// client is dropped when a request fails with 401 although the TokenSource was asked for a new
+// key, or when the pool receives a token.revoked event for its organization, so the next call of
+// Client creates it with fresh credentials.
+type ClientPool struct {
+	config      func(ctx context.Context, organizationID string) (Config, error)
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	tenants   map[string]*tenant
+	lastSweep time.Time
+}
+
+type tenant struct {
+	client      *Client
+	rateLimiter RateLimiter
+	lastUsed    time.Time
+}
+
+// NewClientPool creates a new ClientPool.
+func NewClientPool(config PoolConfig) (*ClientPool, error) {
+	if config.Config == nil {
+		return nil, fmt.Errorf("config function is required")
+	}
+	idleTimeout := config.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	return &ClientPool{
+		config:      config.Config,
+		idleTimeout: idleTimeout,
+		tenants:     make(map[string]*tenant),
+	}, nil
+}
+
+// Client returns the client of an organization, creating it if necessary.
+func (p *ClientPool) Client(ctx context.Context, organizationID string) (*Client, error) {
+	now := time.Now()
+
+	p.mu.Lock()
+	p.sweep(now)
+	t, ok := p.tenants[organizationID]
+	if ok && t.client != nil {
+		t.lastUsed = now
+		p.mu.Unlock()
+		return t.client, nil
+	}
+	p.mu.Unlock()
+
+	config, err := p.config(ctx, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for organization %s: %w", organizationID, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, ok = p.tenants[organizationID]
+	if !ok {
+		t = &tenant{}
+		p.tenants[organizationID] = t
+	}
+	t.lastUsed = now
+	if t.client != nil {
+		// Another goroutine created the client in the meantime.
+		return t.client, nil
+	}
+
+	if config.RateLimiter == nil {
+		if t.rateLimiter == nil {
+			t.rateLimiter = newRateLimiter(config)
+		}
+		config.RateLimiter = t.rateLimiter
+	}
+
+	client, err := NewClientWithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	// Only a 401 that persists after the TokenSource was asked again means the credentials are
+	// gone; a rotated key is picked up by the client itself.
+	client.onUnauthorized = func() { p.drop(organizationID, client) }
+	t.client = client
+	return client, nil
+}
+
+// Remove drops the client and rate limiter of an organization from the pool.
+func (p *ClientPool) Remove(organizationID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tenants, organizationID)
+}
+
+// HandleEvent drops the client of the event's organization if its token was revoked. Pass all
+// webhook payloads received from Lexware; other events are ignored.
+func (p *ClientPool) HandleEvent(event *types.WebhookPayload) {
+	if event == nil || event.EventType != types.EventTypeTokenRevoked {
+		return
+	}
+	p.drop(event.OrganizationID, nil)
+}
+
+// Len returns the number of organizations with a cached client.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, t := range p.tenants {
+		if t.client != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// drop removes the client of an organization but keeps its rate limiter. If client is not nil,
+// the organization's client is only dropped if it is still that client.
+func (p *ClientPool) drop(organizationID string, client *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.tenants[organizationID]; ok && (client == nil || t.client == client) {
+		t.client = nil
+	}
+}
+
+// sweep evicts idle organizations. It runs at most twice per idle timeout.
+func (p *ClientPool) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < p.idleTimeout/2 {
+		return
+	}
+	p.lastSweep = now
+	for id, t := range p.tenants {
+		if now.Sub(t.lastUsed) > p.idleTimeout {
+			delete(p.tenants, id)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientPoolUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"organizationId":"org"}`))
+	}))
+	defer srv.Close()
+
+	var key atomic.Value
+	pool, err := NewClientPool(PoolConfig{
+		Config: func(context.Context, string) (Config, error) {
+			return Config{
+				BaseURL:   srv.URL,
+				RateLimit: -1,
+				TokenSource: TokenSourceFunc(func(context.Context) (string, error) {
+					return key.Load().(string), nil
+				}),
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A 401 for a rotated key is retried with the new key and keeps the client.
+	key.Store("rotated")
+	client, err := pool.Client(ctx, "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	client.tokenSource = TokenSourceFunc(func(context.Context) (string, error) {
+		if calls++; calls == 1 {
+			return "rotated", nil
+		}
+		return "valid", nil
+	})
+	if _, err := client.Profile().Get(ctx); err != nil {
+		t.Fatalf("Get with a rotated key returned %v", err)
+	}
+	if again, _ := pool.Client(ctx, "org"); again != client {
+		t.Error("client was dropped although the key rotation succeeded")
+	}
+
+	// A 401 that persists drops the client.
+	client.tokenSource = TokenSourceFunc(func(context.Context) (string, error) { return "revoked", nil })
+	if _, err := client.Profile().Get(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Get with a revoked key returned %v, want ErrUnauthorized", err)
+	}
+	if pool.Len() != 0 {
+		t.Error("client was not dropped after a persistent 401")
+	}
+	key.Store("valid")
+	fresh, err := pool.Client(ctx, "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh == client {
+		t.Error("pool returned the dropped client")
+	}
+	if _, err := fresh.Profile().Get(ctx); err != nil {
+		t.Errorf("Get with the new client returned %v", err)
+	}
+}
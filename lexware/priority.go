@@ -0,0 +1,99 @@
+package lexware
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Priority orders requests waiting for the rate limiter.
+type Priority int
+
+const (
+	// PriorityLow is meant for background work like nightly exports, which waits for all other
+	// requests.
+	PriorityLow Priority = -1
+	// PriorityNormal is the priority of requests without one set.
+	PriorityNormal Priority = 0
+	// PriorityHigh is meant for interactive requests a user waits for. They are sent before all
+	// waiting requests of lower priority.
+	PriorityHigh Priority = 1
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context that makes requests sent with it wait for the rate limiter with
+// priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, or PriorityNormal. Custom
+// RateLimiter implementations may use it to order waiting requests.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+	return p
+}
+
+// priorityQueue admits one holder at a time. Waiting callers are admitted by priority first and
+// in arrival order second. The zero value is ready to use.
+type priorityQueue struct {
+	mu   sync.Mutex
+	busy bool
+	// waiting holds the waiters of PriorityNormal and above at index 0, and those below at 1.
+	waiting [2][]chan struct{}
+}
+
+func lane(p Priority) int {
+	if p < PriorityNormal {
+		return 1
+	}
+	return 0
+}
+
+// acquire blocks until the caller is admitted or ctx is done.
+func (q *priorityQueue) acquire(ctx context.Context, p Priority) error {
+	q.mu.Lock()
+	if !q.busy {
+		q.busy = true
+		q.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	l := lane(p)
+	q.waiting[l] = append(q.waiting[l], ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		if i := slices.Index(q.waiting[l], ready); i >= 0 {
+			q.waiting[l] = slices.Delete(q.waiting[l], i, i+1)
+			q.mu.Unlock()
+			return ctx.Err()
+		}
+		q.mu.Unlock()
+		// Admitted concurrently with the cancellation, pass the turn on.
+		q.release()
+		return ctx.Err()
+	}
+}
+
+// release admits the next waiter.
+func (q *priorityQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for l, waiting := range q.waiting {
+		if len(waiting) > 0 {
+			close(waiting[0])
+			q.waiting[l] = waiting[1:]
+			return
+		}
+	}
+	q.busy = false
+}
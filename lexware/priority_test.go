@@ -0,0 +1,241 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitQueued waits until n callers wait for q.
+func waitQueued(t *testing.T, q *priorityQueue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		q.mu.Lock()
+		queued := len(q.waiting[0]) + len(q.waiting[1])
+		q.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers waiting, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitBusy waits until a caller holds q.
+func waitBusy(t *testing.T, q *priorityQueue) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		q.mu.Lock()
+		busy := q.busy
+		q.mu.Unlock()
+		if busy {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no caller holds the queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	type waiter struct {
+		name     string
+		priority Priority
+	}
+	tests := []struct {
+		name    string
+		waiters []waiter
+		want    []string
+	}{
+		{
+			name:    "arrival order within a priority",
+			waiters: []waiter{{"a", PriorityNormal}, {"b", PriorityNormal}, {"c", PriorityNormal}},
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name:    "low priority waits for normal priority",
+			waiters: []waiter{{"low1", PriorityLow}, {"normal1", PriorityNormal}, {"low2", PriorityLow}, {"normal2", PriorityNormal}},
+			want:    []string{"normal1", "normal2", "low1", "low2"},
+		},
+		{
+			name:    "low priority only",
+			waiters: []waiter{{"a", PriorityLow}, {"b", PriorityLow}},
+			want:    []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q priorityQueue
+			if err := q.acquire(context.Background(), PriorityNormal); err != nil {
+				t.Fatal(err)
+			}
+			admitted := make(chan string)
+			for i, w := range tt.waiters {
+				go func() {
+					if err := q.acquire(context.Background(), w.priority); err != nil {
+						t.Error(err)
+					}
+					admitted <- w.name
+				}()
+				waitQueued(t, &q, i+1)
+			}
+
+			var got []string
+			for range tt.waiters {
+				q.release()
+				got = append(got, <-admitted)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("admitted %v, want %v", got, tt.want)
+			}
+			q.release()
+			if q.busy {
+				t.Error("queue still busy after the last release")
+			}
+		})
+	}
+}
+
+func TestPriorityQueueCancel(t *testing.T) {
+	var q priorityQueue
+	if err := q.acquire(context.Background(), PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() { canceled <- q.acquire(ctx, PriorityNormal) }()
+	waitQueued(t, &q, 1)
+	admitted := make(chan error)
+	go func() { admitted <- q.acquire(context.Background(), PriorityLow) }()
+	waitQueued(t, &q, 2)
+
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() of the canceled caller = %v, want context.Canceled", err)
+	}
+	waitQueued(t, &q, 1)
+
+	// The turn goes to the remaining caller instead of the canceled one.
+	q.release()
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatalf("acquire() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("remaining caller not admitted")
+	}
+	q.release()
+	if q.busy {
+		t.Error("queue still busy after the last release")
+	}
+}
+
+func TestPriorityQueueCanceledBeforeWaiting(t *testing.T) {
+	var q priorityQueue
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// A free queue admits callers without looking at ctx.
+	if err := q.acquire(ctx, PriorityNormal); err != nil {
+		t.Fatalf("acquire() of a free queue = %v, want nil", err)
+	}
+	if err := q.acquire(ctx, PriorityNormal); !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() of a busy queue = %v, want context.Canceled", err)
+	}
+	waitQueued(t, &q, 0)
+}
+
+func TestAdaptiveRateLimiterWaitOrder(t *testing.T) {
+	l := NewAdaptiveRateLimiter(10)
+	ctx := context.Background()
+	// Takes the first slot, so the next caller holds the queue while it waits for its own.
+	if err := l.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	type waiter struct {
+		name     string
+		priority Priority
+	}
+	waiters := []waiter{{"first", PriorityNormal}, {"low1", PriorityLow}, {"normal1", PriorityNormal}, {"low2", PriorityLow}, {"normal2", PriorityNormal}}
+	admitted := make(chan string, len(waiters))
+	for i, w := range waiters {
+		go func() {
+			if err := l.Wait(WithPriority(ctx, w.priority)); err != nil {
+				t.Error(err)
+			}
+			admitted <- w.name
+		}()
+		if i == 0 {
+			waitBusy(t, &l.queue)
+		}
+		waitQueued(t, &l.queue, i)
+	}
+
+	var got []string
+	for range waiters {
+		got = append(got, <-admitted)
+	}
+	if want := []string{"first", "normal1", "normal2", "low1", "low2"}; !slices.Equal(got, want) {
+		t.Errorf("admitted %v, want %v", got, want)
+	}
+}
+
+func TestAdaptiveRateLimiterHighPriorityReserve(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	l := NewAdaptiveRateLimiter(float64(time.Second / interval))
+	l.SetHighPriorityReserve(0.5)
+	ctx := context.Background()
+
+	// Normal requests keep the limiter busy, with more of them queued than it can admit.
+	busy, stop := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var normal []time.Time
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l.Wait(busy) == nil {
+				mu.Lock()
+				normal = append(normal, time.Now())
+				mu.Unlock()
+			}
+		}()
+	}
+	waitQueued(t, &l.queue, 3)
+	time.Sleep(5 * interval)
+
+	// A high priority request neither waits behind the queued ones nor for the reserved slots.
+	start := time.Now()
+	if err := l.Wait(WithPriority(ctx, PriorityHigh)); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited > 3*interval {
+		t.Errorf("high priority request waited %v with normal requests queued, want at most %v", waited, 3*interval)
+	}
+
+	time.Sleep(10 * interval)
+	stop()
+	wg.Wait()
+
+	// Normal requests only get half of the rate.
+	mu.Lock()
+	defer mu.Unlock()
+	if len(normal) < 2 {
+		t.Fatalf("%d normal requests admitted", len(normal))
+	}
+	spacing := normal[len(normal)-1].Sub(normal[0]) / time.Duration(len(normal)-1)
+	if min := 2 * interval * 9 / 10; spacing < min {
+		t.Errorf("normal requests were admitted every %v, want at least %v", spacing, min)
+	}
+}
@@ -0,0 +1,218 @@
+package lexware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter decides when the client may send the next request. Implementations must be safe
+// for concurrent use.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent or ctx is done.
+	Wait(ctx context.Context) error
+	// ReportThrottled is called when the API answered with 429. retryAfter is the delay the
+	// client waits before retrying.
+	ReportThrottled(retryAfter time.Duration)
+	// ReportSuccess is called when the API answered without throttling the request.
+	ReportSuccess()
+}
+
+const (
+	// maxSlowdown bounds how far an AdaptiveRateLimiter lowers its rate after 429s.
+	maxSlowdown = 8
+	// recoverAfter is the number of unthrottled requests after which an AdaptiveRateLimiter
+	// raises its rate again.
+	recoverAfter = 10
+	// recoverFactor is the factor the request interval shrinks by when recovering.
+	recoverFactor = 0.8
+)
+
+// RateLimitState is the state of an AdaptiveRateLimiter as kept in a RateLimitStore.
+type RateLimitState struct {
+	// Next is the earliest time the next request may be sent.
+	Next time.Time `json:"next"`
+	// Interval is the current minimum interval between two requests.
+	Interval time.Duration `json:"interval"`
+	// PausedUntil is set after a 429 to hold back all requests for the Retry-After delay.
+	PausedUntil time.Time `json:"pausedUntil"`
+	// Successes counts the unthrottled requests since the interval was last changed.
+	Successes int `json:"successes"`
+	// NextLowPriority is the earliest time the next request below PriorityHigh may be sent while
+	// part of the rate is reserved for PriorityHigh.
+	NextLowPriority time.Time `json:"nextLowPriority"`
+}
+
+// RateLimitStore holds the state of rate limiters, possibly shared between processes.
+type RateLimitStore interface {
+	// Update atomically applies fn to the state stored under key. A missing state is passed
+	// to fn as the zero value.
+	Update(ctx context.Context, key string, fn func(state *RateLimitState)) error
+}
+
+// AdaptiveRateLimiter spaces requests evenly at a configured rate. When the API answers with
+// 429, it halves its rate (down to an eighth of the configured one) and pauses for the
+// Retry-After delay; after every ten unthrottled requests it gradually recovers.
+//
+// Limiters sharing a RateLimitStore and key share one budget, e.g. workers in several
+// processes using the same API key with a FileRateLimitStore.
+//
+// Requests with PriorityHigh are sent before waiting requests of lower priority, and requests
+// with PriorityNormal before those with PriorityLow. See SetHighPriorityReserve to keep part of
+// the rate free for PriorityHigh requests.
+type AdaptiveRateLimiter struct {
+	store        RateLimitStore
+	key          string
+	baseInterval time.Duration
+	// queue lets requests below PriorityHigh reserve a slot one at a time, so they don't book
+	// slots ahead of later requests of higher priority.
+	queue priorityQueue
+
+	mu      sync.Mutex
+	reserve float64
+}
+
+// NewAdaptiveRateLimiter creates an in-memory AdaptiveRateLimiter allowing requestsPerSecond.
+// It panics if requestsPerSecond is not positive.
+func NewAdaptiveRateLimiter(requestsPerSecond float64) *AdaptiveRateLimiter {
+	return NewSharedRateLimiter(NewMemoryRateLimitStore(), "", requestsPerSecond)
+}
+
+// NewSharedRateLimiter creates an AdaptiveRateLimiter allowing requestsPerSecond, keeping its
+// state in store under key. All limiters using the same store and key share the rate. It panics
+// if requestsPerSecond is not positive; use no RateLimiter at all to disable rate limiting.
+func NewSharedRateLimiter(store RateLimitStore, key string, requestsPerSecond float64) *AdaptiveRateLimiter {
+	if !(requestsPerSecond > 0) {
+		panic(fmt.Sprintf("lexware: rate limit must be positive, got %v", requestsPerSecond))
+	}
+	return &AdaptiveRateLimiter{
+		store:        store,
+		key:          key,
+		baseInterval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+// SetHighPriorityReserve reserves share of the rate for PriorityHigh requests, e.g. 0.25 for a
+// quarter. Requests of lower priority never use more than the rest of the rate, even while
+// there are no PriorityHigh requests. With a shared RateLimitStore the reserve holds across
+// processes. share is capped at 0.9.
+func (l *AdaptiveRateLimiter) SetHighPriorityReserve(share float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reserve = min(max(share, 0), 0.9)
+}
+
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	priority := PriorityFromContext(ctx)
+	if priority < PriorityHigh {
+		if err := l.queue.acquire(ctx, priority); err != nil {
+			return err
+		}
+		defer l.queue.release()
+	}
+	l.mu.Lock()
+	reserve := l.reserve
+	l.mu.Unlock()
+
+	for {
+		var at time.Time
+		var reserved bool
+		err := l.store.Update(ctx, l.key, func(s *RateLimitState) {
+			l.init(s)
+			at = time.Now()
+			if s.PausedUntil.After(at) {
+				// Wait for the pause to end before reserving a slot.
+				at = s.PausedUntil
+				return
+			}
+			if s.Next.After(at) {
+				at = s.Next
+			}
+			if priority < PriorityHigh && s.NextLowPriority.After(at) {
+				at = s.NextLowPriority
+			}
+			s.Next = at.Add(s.Interval)
+			if priority < PriorityHigh && reserve > 0 {
+				s.NextLowPriority = at.Add(time.Duration(float64(s.Interval) / (1 - reserve)))
+			}
+			reserved = true
+		})
+		if err != nil {
+			return err
+		}
+		if err := sleepContext(ctx, time.Until(at)); err != nil {
+			return err
+		}
+
+		if !reserved {
+			continue
+		}
+
+		// A 429 may have paused the limiter while this request waited for its slot.
+		var paused bool
+		err = l.store.Update(ctx, l.key, func(s *RateLimitState) {
+			paused = s.PausedUntil.After(time.Now())
+		})
+		if err != nil {
+			return err
+		}
+		if !paused {
+			return nil
+		}
+	}
+}
+
+func (l *AdaptiveRateLimiter) ReportThrottled(retryAfter time.Duration) {
+	_ = l.store.Update(context.Background(), l.key, func(s *RateLimitState) {
+		l.init(s)
+		s.Interval = min(s.Interval*2, l.baseInterval*maxSlowdown)
+		s.Successes = 0
+		if resume := time.Now().Add(retryAfter); resume.After(s.PausedUntil) {
+			s.PausedUntil = resume
+		}
+	})
+}
+
+func (l *AdaptiveRateLimiter) ReportSuccess() {
+	_ = l.store.Update(context.Background(), l.key, func(s *RateLimitState) {
+		l.init(s)
+		if s.Interval <= l.baseInterval {
+			return
+		}
+		s.Successes++
+		if s.Successes >= recoverAfter {
+			s.Interval = max(time.Duration(float64(s.Interval)*recoverFactor), l.baseInterval)
+			s.Successes = 0
+		}
+	})
+}
+
+func (l *AdaptiveRateLimiter) init(s *RateLimitState) {
+	if s.Interval < l.baseInterval {
+		s.Interval = l.baseInterval
+	}
+}
+
+// MemoryRateLimitStore keeps rate limiter state in memory.
+type MemoryRateLimitStore struct {
+	mu     sync.Mutex
+	states map[string]*RateLimitState
+}
+
+// NewMemoryRateLimitStore creates an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{states: make(map[string]*RateLimitState)}
+}
+
+func (s *MemoryRateLimitStore) Update(_ context.Context, key string, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		state = &RateLimitState{}
+		s.states[key] = state
+	}
+	fn(state)
+	return nil
+}
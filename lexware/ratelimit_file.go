@@ -0,0 +1,98 @@
+package lexware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileRateLimitStore keeps rate limiter state in a JSON file guarded by an exclusive file lock,
+// so that all processes on a host using the same file share their rate limits.
+type FileRateLimitStore struct {
+	path string
+	// mu serializes updates within the process, the file lock across processes.
+	mu sync.Mutex
+}
+
+// NewFileRateLimitStore creates a FileRateLimitStore using the file at path. The file is created
+// on first use, together with a lock file at path + ".lock". It fails on platforms without file
+// locking, which are all but unix.
+func NewFileRateLimitStore(path string) (*FileRateLimitStore, error) {
+	if err := checkFileLocking(); err != nil {
+		return nil, err
+	}
+	return &FileRateLimitStore{path: path}, nil
+}
+
+func (s *FileRateLimitStore) Update(ctx context.Context, key string, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The state file is replaced on every update, so the lock is kept on a file of its own.
+	lock, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open rate limit lock file: %w", err)
+	}
+	defer lock.Close()
+
+	if err := lockFile(ctx, lock); err != nil {
+		return fmt.Errorf("failed to lock rate limit file: %w", err)
+	}
+	defer unlockFile(lock)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read rate limit file: %w", err)
+	}
+	states := make(map[string]*RateLimitState)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &states); err != nil {
+			// A corrupt file would fail all requests of all processes for good. Losing the state
+			// only costs a few requests that aren't spaced out.
+			states = make(map[string]*RateLimitState)
+		}
+	}
+
+	state, ok := states[key]
+	if !ok || state == nil {
+		state = &RateLimitState{}
+		states[key] = state
+	}
+	fn(state)
+
+	if data, err = json.Marshal(states); err != nil {
+		return fmt.Errorf("failed to encode rate limit file: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write rate limit file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data, so readers see either the old or the new
+// content even if the process crashes while writing.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+//go:build unix
+
+package lexware
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileRateLimitStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	ctx := context.Background()
+
+	// Two stores on the same file stand in for two processes.
+	stores := make([]*FileRateLimitStore, 2)
+	for i := range stores {
+		store, err := NewFileRateLimitStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[i] = store
+	}
+
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := store.Update(ctx, "org", func(s *RateLimitState) { s.Successes++ }); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	var got int
+	if err := stores[1].Update(ctx, "org", func(s *RateLimitState) { got = s.Successes }); err != nil {
+		t.Fatal(err)
+	}
+	if got != 40 {
+		t.Errorf("Successes = %d, want 40 after concurrent updates", got)
+	}
+	if err := stores[0].Update(ctx, "other", func(s *RateLimitState) { got = s.Successes }); err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("Successes of another key = %d, want 0", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states map[string]*RateLimitState
+	if err := json.Unmarshal(data, &states); err != nil {
+		t.Fatalf("rate limit file is not valid JSON: %v", err)
+	}
+	if leftovers, _ := filepath.Glob(path + ".*.tmp"); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestFileRateLimitStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	// A file truncated in the middle of a write.
+	if err := os.WriteFile(path, []byte(`{"org":{"interval":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileRateLimitStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state RateLimitState
+	err = store.Update(context.Background(), "org", func(s *RateLimitState) {
+		state = *s
+		s.Successes = 1
+	})
+	if err != nil {
+		t.Fatalf("Update() on a corrupt file = %v, want nil", err)
+	}
+	if state != (RateLimitState{}) {
+		t.Errorf("state read from a corrupt file = %+v, want the zero value", state)
+	}
+	if err := store.Update(context.Background(), "org", func(s *RateLimitState) { state = *s }); err != nil {
+		t.Fatal(err)
+	}
+	if state.Successes != 1 {
+		t.Errorf("Successes = %d, want 1 after the file was rewritten", state.Successes)
+	}
+}
+
+func TestFileRateLimitStoreLimiter(t *testing.T) {
+	store, err := NewFileRateLimitStore(filepath.Join(t.TempDir(), "ratelimit.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewSharedRateLimiter(store, "org", 100)
+	b := NewSharedRateLimiter(store, "org", 100)
+	a.ReportThrottled(0)
+	if got, want := limiterInterval(t, b), 2*a.baseInterval; got != want {
+		t.Errorf("interval = %v, want %v", got, want)
+	}
+}
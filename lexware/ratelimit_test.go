@@ -0,0 +1,132 @@
+package lexware
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// limiterInterval returns the current interval of l.
+func limiterInterval(t *testing.T, l *AdaptiveRateLimiter) time.Duration {
+	t.Helper()
+	var interval time.Duration
+	if err := l.store.Update(context.Background(), l.key, func(s *RateLimitState) {
+		l.init(s)
+		interval = s.Interval
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return interval
+}
+
+func TestAdaptiveRateLimiterInterval(t *testing.T) {
+	const base = 10 * time.Millisecond // 100 requests per second
+
+	tests := []struct {
+		name      string
+		throttled int
+		successes int
+		want      time.Duration
+	}{
+		{name: "unthrottled", successes: 50, want: base},
+		{name: "one 429 halves the rate", throttled: 1, want: 2 * base},
+		{name: "two 429s", throttled: 2, want: 4 * base},
+		{name: "slowdown is capped", throttled: 10, want: maxSlowdown * base},
+		{name: "too few successes to recover", throttled: 1, successes: recoverAfter - 1, want: 2 * base},
+		{name: "recovers gradually", throttled: 1, successes: recoverAfter, want: time.Duration(float64(2*base) * recoverFactor)},
+		{name: "recovers twice", throttled: 3, successes: 2 * recoverAfter, want: time.Duration(float64(time.Duration(float64(8*base)*recoverFactor)) * recoverFactor)},
+		{name: "recovers fully", throttled: 10, successes: 20 * recoverAfter, want: base},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewAdaptiveRateLimiter(100)
+			for range tt.throttled {
+				l.ReportThrottled(0)
+			}
+			for range tt.successes {
+				l.ReportSuccess()
+			}
+			if got := limiterInterval(t, l); got != tt.want {
+				t.Errorf("interval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveRateLimiterThrottledResetsSuccesses(t *testing.T) {
+	l := NewAdaptiveRateLimiter(100)
+	l.ReportThrottled(0)
+	for range recoverAfter - 1 {
+		l.ReportSuccess()
+	}
+	l.ReportThrottled(0)
+	l.ReportSuccess()
+	if got, want := limiterInterval(t, l), 40*time.Millisecond; got != want {
+		t.Errorf("interval = %v, want %v", got, want)
+	}
+}
+
+func TestAdaptiveRateLimiterWait(t *testing.T) {
+	l := NewAdaptiveRateLimiter(100)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 6 {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request passes at once, the others are spaced 10ms apart.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("6 requests at 100/s took %v, want at least 50ms", elapsed)
+	}
+}
+
+func TestAdaptiveRateLimiterPause(t *testing.T) {
+	l := NewAdaptiveRateLimiter(1000)
+	l.ReportThrottled(100 * time.Millisecond)
+
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Wait after a 429 returned after %v, want the Retry-After delay of 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	l.ReportThrottled(time.Second)
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait during a pause = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestAdaptiveRateLimiterSharedStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	a := NewSharedRateLimiter(store, "org", 100)
+	b := NewSharedRateLimiter(store, "org", 100)
+	other := NewSharedRateLimiter(store, "other", 100)
+
+	a.ReportThrottled(0)
+	if got, want := limiterInterval(t, b), 20*time.Millisecond; got != want {
+		t.Errorf("interval of a limiter sharing the key = %v, want %v", got, want)
+	}
+	if got, want := limiterInterval(t, other), 10*time.Millisecond; got != want {
+		t.Errorf("interval of a limiter with another key = %v, want %v", got, want)
+	}
+}
+
+func TestNewSharedRateLimiterInvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewSharedRateLimiter(%v) didn't panic", rate)
+				}
+			}()
+			NewSharedRateLimiter(NewMemoryRateLimitStore(), "", rate)
+		}()
+	}
+}
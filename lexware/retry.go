@@ -0,0 +1,134 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryPolicy is used for all fields of Config.Retry that are left unset.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+}
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Requests are retried on network errors and on the status codes 429, 500, 502, 503 and 504.
+// Only idempotent methods (GET, HEAD, PUT, DELETE, OPTIONS) are retried by default, with the
+// exception of 429 responses which are always retried because the request was not processed.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	// Set to 1 to disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles with every further attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts. A Retry-After header sent by the API
+	// takes precedence over the computed backoff.
+	MaxBackoff time.Duration
+	// RetryNonIdempotent enables retrying POST requests on network errors and 5xx responses.
+	// Be aware that this may create duplicate resources.
+	RetryNonIdempotent bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	return p
+}
+
+// shouldRetry reports whether a request with the given outcome may be sent again.
+func (p RetryPolicy) shouldRetry(method string, resp *http.Response, err error) bool {
+	if resp == nil {
+		// Only errors of the HTTP client itself are worth retrying, errors returned by
+		// middleware are final.
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return p.RetryNonIdempotent || isIdempotent(method)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return p.RetryNonIdempotent || isIdempotent(method)
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry using exponential backoff with jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	// Equal jitter: keep half of the delay and randomize the other half.
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drainAndClose discards a bounded amount of the body so the connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
@@ -0,0 +1,85 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	netErr := &url.Error{Op: "Get", URL: "https://api.lexware.io/v1/contacts", Err: io.ErrUnexpectedEOF}
+	canceled := &url.Error{Op: "Get", URL: "https://api.lexware.io/v1/contacts", Err: context.Canceled}
+	timedOut := &url.Error{Op: "Get", URL: "https://api.lexware.io/v1/contacts", Err: context.DeadlineExceeded}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "network error GET", method: "GET", err: netErr, want: true},
+		{name: "network error PUT", method: "PUT", err: netErr, want: true},
+		{name: "network error POST", method: "POST", err: netErr, want: false},
+		{name: "network error POST non-idempotent", policy: RetryPolicy{RetryNonIdempotent: true}, method: "POST", err: netErr, want: true},
+		{name: "canceled", method: "GET", err: canceled, want: false},
+		{name: "deadline exceeded", method: "GET", err: timedOut, want: false},
+		{name: "middleware error", method: "GET", err: errors.New("rejected by middleware"), want: false},
+		{name: "429 GET", method: "GET", status: 429, want: true},
+		{name: "429 POST", method: "POST", status: 429, want: true},
+		{name: "500 GET", method: "GET", status: 500, want: true},
+		{name: "502 DELETE", method: "DELETE", status: 502, want: true},
+		{name: "503 GET", method: "GET", status: 503, want: true},
+		{name: "504 GET", method: "GET", status: 504, want: true},
+		{name: "503 POST", method: "POST", status: 503, want: false},
+		{name: "503 POST non-idempotent", policy: RetryPolicy{RetryNonIdempotent: true}, method: "POST", status: 503, want: true},
+		{name: "501 GET", method: "GET", status: 501, want: false},
+		{name: "400 GET", method: "GET", status: 400, want: false},
+		{name: "404 GET", method: "GET", status: 404, want: false},
+		{name: "409 PUT", method: "PUT", status: 409, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.status != 0 {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := tt.policy.shouldRetry(tt.method, resp, tt.err); got != tt.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "empty", value: "", want: 0, wantOK: false},
+		{name: "seconds", value: "120", want: 2 * time.Minute, wantOK: true},
+		{name: "zero seconds", value: "0", want: 0, wantOK: true},
+		{name: "negative seconds", value: "-1", want: 0, wantOK: false},
+		{name: "fractional seconds", value: "1.5", want: 0, wantOK: false},
+		{name: "future date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second, wantOK: true},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{name: "garbage", value: "soon", want: 0, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -14,23 +13,19 @@ import (
 type quotationsClient struct{ client *Client }
 
 func (c *quotationsClient) Create(ctx context.Context, quotation *types.QuotationCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/quotations"
-	if finalize {
-		path += "?finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, quotation)
+	path, err := createPath("/v1/quotations", finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "Quotations.Create"}, path, quotation)
 }
 
 func (c *quotationsClient) Get(ctx context.Context, id string) (*types.Quotation, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/quotations/"+id, nil)
+	path, err := idPath("/v1/quotations", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Quotations.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -42,19 +37,22 @@ func (c *quotationsClient) Get(ctx context.Context, id string) (*types.Quotation
 }
 
 func (c *quotationsClient) RenderDocument(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "GET", "/v1/quotations/"+id+"/document", nil)
+	path, err := idPath("/v1/quotations", id, "document")
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "Quotations.RenderDocument", resourceID: id}, "GET", path, nil)
 	return err
 }
 
 func (c *quotationsClient) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/quotations/"+id+"/document", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/quotations", id, "document")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "Quotations.DownloadDocument", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
@@ -63,23 +61,19 @@ func (c *quotationsClient) DownloadDocument(ctx context.Context, id string) (io.
 type creditNotesClient struct{ client *Client }
 
 func (c *creditNotesClient) Create(ctx context.Context, creditNote *types.CreditNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/credit-notes"
-	if finalize {
-		path += "?finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, creditNote)
+	path, err := createPath("/v1/credit-notes", finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "CreditNotes.Create"}, path, creditNote)
 }
 
 func (c *creditNotesClient) Get(ctx context.Context, id string) (*types.CreditNote, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/credit-notes/"+id, nil)
+	path, err := idPath("/v1/credit-notes", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "CreditNotes.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -91,35 +85,30 @@ func (c *creditNotesClient) Get(ctx context.Context, id string) (*types.CreditNo
 }
 
 func (c *creditNotesClient) Pursue(ctx context.Context, precedingSalesVoucherID string, creditNote *types.CreditNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/credit-notes?precedingSalesVoucherId=" + precedingSalesVoucherID
-	if finalize {
-		path += "&finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, creditNote)
+	path, err := pursuePath("/v1/credit-notes", precedingSalesVoucherID, finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "CreditNotes.Pursue", resourceID: precedingSalesVoucherID}, path, creditNote)
 }
 
 func (c *creditNotesClient) RenderDocument(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "GET", "/v1/credit-notes/"+id+"/document", nil)
+	path, err := idPath("/v1/credit-notes", id, "document")
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "CreditNotes.RenderDocument", resourceID: id}, "GET", path, nil)
 	return err
 }
 
 func (c *creditNotesClient) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/credit-notes/"+id+"/document", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/credit-notes", id, "document")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "CreditNotes.DownloadDocument", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
@@ -128,23 +117,19 @@ func (c *creditNotesClient) DownloadDocument(ctx context.Context, id string) (io
 type deliveryNotesClient struct{ client *Client }
 
 func (c *deliveryNotesClient) Create(ctx context.Context, deliveryNote *types.DeliveryNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/delivery-notes"
-	if finalize {
-		path += "?finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, deliveryNote)
+	path, err := createPath("/v1/delivery-notes", finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "DeliveryNotes.Create"}, path, deliveryNote)
 }
 
 func (c *deliveryNotesClient) Get(ctx context.Context, id string) (*types.DeliveryNote, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/delivery-notes/"+id, nil)
+	path, err := idPath("/v1/delivery-notes", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "DeliveryNotes.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -156,35 +141,30 @@ func (c *deliveryNotesClient) Get(ctx context.Context, id string) (*types.Delive
 }
 
 func (c *deliveryNotesClient) Pursue(ctx context.Context, precedingSalesVoucherID string, deliveryNote *types.DeliveryNoteCreateRequest, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/delivery-notes?precedingSalesVoucherId=" + precedingSalesVoucherID
-	if finalize {
-		path += "&finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, deliveryNote)
+	path, err := pursuePath("/v1/delivery-notes", precedingSalesVoucherID, finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "DeliveryNotes.Pursue", resourceID: precedingSalesVoucherID}, path, deliveryNote)
 }
 
 func (c *deliveryNotesClient) RenderDocument(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "GET", "/v1/delivery-notes/"+id+"/document", nil)
+	path, err := idPath("/v1/delivery-notes", id, "document")
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "DeliveryNotes.RenderDocument", resourceID: id}, "GET", path, nil)
 	return err
 }
 
 func (c *deliveryNotesClient) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/delivery-notes/"+id+"/document", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/delivery-notes", id, "document")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "DeliveryNotes.DownloadDocument", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
@@ -193,20 +173,24 @@ func (c *deliveryNotesClient) DownloadDocument(ctx context.Context, id string) (
 type dunningsClient struct{ client *Client }
 
 func (c *dunningsClient) Create(ctx context.Context, precedingSalesVoucherID string, dunning *types.DunningCreateRequest) (*types.ActionResult, error) {
-	path := "/v1/dunnings?precedingSalesVoucherId=" + precedingSalesVoucherID
-	body, err := c.client.doRequest(ctx, "POST", path, dunning)
+	return c.create(ctx, "Dunnings.Create", precedingSalesVoucherID, dunning)
+}
+
+// create creates a dunning; Create and Pursue only differ in the operation name.
+func (c *dunningsClient) create(ctx context.Context, name, precedingSalesVoucherID string, dunning *types.DunningCreateRequest) (*types.ActionResult, error) {
+	path, err := pursuePath("/v1/dunnings", precedingSalesVoucherID, false)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: name, resourceID: precedingSalesVoucherID}, path, dunning)
 }
 
 func (c *dunningsClient) Get(ctx context.Context, id string) (*types.Dunning, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/dunnings/"+id, nil)
+	path, err := idPath("/v1/dunnings", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Dunnings.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -218,23 +202,26 @@ func (c *dunningsClient) Get(ctx context.Context, id string) (*types.Dunning, er
 }
 
 func (c *dunningsClient) Pursue(ctx context.Context, precedingSalesVoucherID string, dunning *types.DunningCreateRequest) (*types.ActionResult, error) {
-	return c.Create(ctx, precedingSalesVoucherID, dunning)
+	return c.create(ctx, "Dunnings.Pursue", precedingSalesVoucherID, dunning)
 }
 
 func (c *dunningsClient) RenderDocument(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "GET", "/v1/dunnings/"+id+"/document", nil)
+	path, err := idPath("/v1/dunnings", id, "document")
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "Dunnings.RenderDocument", resourceID: id}, "GET", path, nil)
 	return err
 }
 
 func (c *dunningsClient) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/dunnings/"+id+"/document", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/dunnings", id, "document")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "Dunnings.DownloadDocument", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
@@ -243,23 +230,19 @@ func (c *dunningsClient) DownloadDocument(ctx context.Context, id string) (io.Re
 type orderConfirmationsClient struct{ client *Client }
 
 func (c *orderConfirmationsClient) Create(ctx context.Context, orderConfirmation *types.OrderConfirmation, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/order-confirmations"
-	if finalize {
-		path += "?finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, orderConfirmation)
+	path, err := createPath("/v1/order-confirmations", finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "OrderConfirmations.Create"}, path, orderConfirmation)
 }
 
 func (c *orderConfirmationsClient) Get(ctx context.Context, id string) (*types.OrderConfirmation, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/order-confirmations/"+id, nil)
+	path, err := idPath("/v1/order-confirmations", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "OrderConfirmations.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -271,35 +254,30 @@ func (c *orderConfirmationsClient) Get(ctx context.Context, id string) (*types.O
 }
 
 func (c *orderConfirmationsClient) Pursue(ctx context.Context, precedingSalesVoucherID string, orderConfirmation *types.OrderConfirmation, finalize bool) (*types.ActionResult, error) {
-	path := "/v1/order-confirmations?precedingSalesVoucherId=" + precedingSalesVoucherID
-	if finalize {
-		path += "&finalize=true"
-	}
-	body, err := c.client.doRequest(ctx, "POST", path, orderConfirmation)
+	path, err := pursuePath("/v1/order-confirmations", precedingSalesVoucherID, finalize)
 	if err != nil {
 		return nil, err
 	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "OrderConfirmations.Pursue", resourceID: precedingSalesVoucherID}, path, orderConfirmation)
 }
 
 func (c *orderConfirmationsClient) RenderDocument(ctx context.Context, id string) error {
-	_, err := c.client.doRequest(ctx, "GET", "/v1/order-confirmations/"+id+"/document", nil)
+	path, err := idPath("/v1/order-confirmations", id, "document")
+	if err != nil {
+		return err
+	}
+	_, err = c.client.doRequest(ctx, operation{name: "OrderConfirmations.RenderDocument", resourceID: id}, "GET", path, nil)
 	return err
 }
 
 func (c *orderConfirmationsClient) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/order-confirmations/"+id+"/document", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/order-confirmations", id, "document")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "OrderConfirmations.DownloadDocument", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
@@ -308,7 +286,11 @@ func (c *orderConfirmationsClient) DownloadDocument(ctx context.Context, id stri
 type downPaymentInvoicesClient struct{ client *Client }
 
 func (c *downPaymentInvoicesClient) Get(ctx context.Context, id string) (*types.DownPaymentInvoice, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/down-payment-invoices/"+id, nil)
+	path, err := idPath("/v1/down-payment-invoices", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "DownPaymentInvoices.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -320,14 +302,13 @@ func (c *downPaymentInvoicesClient) Get(ctx context.Context, id string) (*types.
 }
 
 func (c *downPaymentInvoicesClient) DownloadDocument(ctx context.Context, id string) (io.ReadCloser, error) {
-	resp, err := c.client.doRequestRaw(ctx, "GET", "/v1/down-payment-invoices/"+id+"/document", nil, map[string]string{"Accept": "application/pdf"})
+	path, err := idPath("/v1/down-payment-invoices", id, "document")
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	resp, err := c.client.doRequestRaw(ctx, operation{name: "DownPaymentInvoices.DownloadDocument", resourceID: id}, "GET", path, nil, map[string]string{"Accept": "application/pdf"})
+	if err != nil {
+		return nil, err
 	}
 	return resp.Body, nil
 }
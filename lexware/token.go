@@ -0,0 +1,79 @@
+package lexware
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the API key used to authenticate requests. It is consulted for every
+// request, so a rotated key is picked up without rebuilding the client. When the API answers
+// with 401, the client asks the TokenSource again and retries once if the key changed.
+// Implementations must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) { return f(ctx) }
+
+// StaticTokenSource returns a TokenSource that always returns apiKey.
+func StaticTokenSource(apiKey string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) { return apiKey, nil })
+}
+
+// EnvTokenSource returns a TokenSource that reads the API key from the environment variable name.
+func EnvTokenSource(name string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		apiKey := os.Getenv(name)
+		if apiKey == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return apiKey, nil
+	})
+}
+
+// FileTokenSource returns a TokenSource that reads the API key from the file at path, e.g. a
+// mounted Kubernetes secret. The file is read again whenever it changes. Surrounding whitespace
+// is trimmed.
+func FileTokenSource(path string) TokenSource {
+	return &fileTokenSource{path: path}
+}
+
+type fileTokenSource struct {
+	path string
+
+	mu      sync.Mutex
+	apiKey  string
+	modTime time.Time
+	size    int64
+}
+
+func (s *fileTokenSource) Token(context.Context) (string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apiKey != "" && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.apiKey, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	apiKey := strings.TrimSpace(string(data))
+	if apiKey == "" {
+		return "", fmt.Errorf("API key file %s is empty", s.path)
+	}
+	s.apiKey, s.modTime, s.size = apiKey, info.ModTime(), info.Size()
+	return apiKey, nil
+}
@@ -0,0 +1,125 @@
+package lexware
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/rasche-thalhofer/lexware-go/lexware"
+
+// requestStats collects what happened while a logical request was sent.
+type requestStats struct {
+	attempts   int
+	statusCode int
+	// rateLimitWait is the total time spent blocked in the rate limiter over all attempts.
+	rateLimitWait time.Duration
+}
+
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+// callSpanKey marks contexts carrying the span of a call of a public method.
+type callSpanKey struct{}
+
+// startCall starts the span of a call of a public method, named after op, e.g. "Invoices.Create".
+// The requests sent with the returned context are recorded as its children, and the methods
+// called with it, e.g. the lookups of doCreate, don't start spans of their own.
+func (c *Client) startCall(ctx context.Context, op operation) (context.Context, trace.Span) {
+	var attrs []attribute.KeyValue
+	if op.resourceID != "" {
+		attrs = append(attrs, attribute.String("lexware.resource_id", op.resourceID))
+	}
+	ctx, span := c.tracer.Start(ctx, op.name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+	return context.WithValue(ctx, callSpanKey{}, true), span
+}
+
+func inCall(ctx context.Context) bool {
+	return ctx.Value(callSpanKey{}) != nil
+}
+
+func endCall(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceAll records the iteration of the iterator returned by all as a call named name, e.g.
+// "Contacts.All", with the requests for all pages as its children.
+func traceAll[T any](c *Client, ctx context.Context, name string, all func(ctx context.Context) iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ctx, span := c.startCall(ctx, operation{name: name})
+		var err error
+		defer func() { endCall(span, err) }()
+		for item, itemErr := range all(ctx) {
+			err = itemErr
+			if !yield(item, itemErr) {
+				return
+			}
+		}
+	}
+}
+
+// startSpan starts the span of a logical request, named after its HTTP method as the
+// OpenTelemetry conventions for HTTP clients suggest. The returned context carries the span, so
+// retries and the HTTP requests of all attempts are recorded as part of it.
+func (c *Client) startSpan(ctx context.Context, r *request) (context.Context, trace.Span) {
+	path, rawQuery, _ := strings.Cut(r.path, "?")
+	attrs := []attribute.KeyValue{
+		attribute.String("lexware.operation", r.op.name),
+		attribute.String("http.request.method", r.method),
+		attribute.String("url.path", path),
+	}
+	if query, err := url.ParseQuery(rawQuery); err == nil && len(query) > 0 {
+		// Filters may contain personal data like email addresses.
+		attrs = append(attrs, attribute.String("url.query", c.redactor.query(query)))
+	}
+	if r.op.resourceID != "" {
+		attrs = append(attrs, attribute.String("lexware.resource_id", r.op.resourceID))
+	}
+	if r.op.paged {
+		attrs = append(attrs, attribute.Int("lexware.page", r.op.page))
+	}
+	return c.tracer.Start(ctx, r.method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, stats *requestStats, err error) {
+	span.SetAttributes(
+		attribute.Int("lexware.attempts", stats.attempts),
+		attribute.Float64("lexware.rate_limiter.wait_seconds", stats.rateLimitWait.Seconds()),
+	)
+	if stats.statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", stats.statusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordRetry adds an event for a retried attempt to the span in ctx.
+func recordRetry(ctx context.Context, attempt int, resp *http.Response, delay time.Duration) {
+	attrs := []attribute.KeyValue{
+		attribute.Int("lexware.attempt", attempt),
+		attribute.Float64("lexware.retry.delay_seconds", delay.Seconds()),
+	}
+	if resp != nil {
+		attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,118 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingTracer records the name, kind and parent of the spans it starts.
+type recordingTracer struct {
+	noop.Tracer
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	noop.Span
+	name   string
+	kind   trace.SpanKind
+	parent *recordingSpan
+	status codes.Code
+	ended  bool
+}
+
+type recordingProvider struct {
+	noop.TracerProvider
+	tracer *recordingTracer
+}
+
+func (p recordingProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return p.tracer
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := trace.SpanFromContext(ctx).(*recordingSpan)
+	config := trace.NewSpanStartConfig(opts...)
+	span := &recordingSpan{name: name, kind: config.SpanKind(), parent: parent}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *recordingSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+func (t *recordingTracer) reset() []*recordingSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := t.spans
+	t.spans = nil
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/contacts":
+			page := r.URL.Query().Get("page")
+			fmt.Fprintf(w, `{"content":[{"id":"contact-%s"}],"first":%t,"last":%t,"totalPages":2,"totalElements":2}`, page, page == "0", page == "1")
+		case "/v1/profile":
+			w.Write([]byte(`{"organizationId":"org"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tracer := &recordingTracer{}
+	client, err := NewClientWithConfig(Config{BaseURL: srv.URL, APIKey: "key", RateLimit: -1, TracerProvider: recordingProvider{tracer: tracer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	check := func(call string, requests int, status codes.Code) {
+		t.Helper()
+		spans := tracer.reset()
+		if len(spans) != requests+1 {
+			t.Fatalf("%s: got %d spans, want %d", call, len(spans), requests+1)
+		}
+		parent := spans[0]
+		if parent.name != call || parent.kind != trace.SpanKindInternal || parent.parent != nil || parent.status != status || !parent.ended {
+			t.Errorf("got call span %q of kind %v with status %v, want %q", parent.name, parent.kind, parent.status, call)
+		}
+		for _, span := range spans[1:] {
+			if span.name != http.MethodGet || span.kind != trace.SpanKindClient || span.parent != parent || !span.ended {
+				t.Errorf("%s: got request span %q of kind %v, want a GET span below the call", call, span.name, span.kind)
+			}
+		}
+	}
+
+	if _, err := client.Profile().Get(ctx); err != nil {
+		t.Fatalf("Get returned %v", err)
+	}
+	check("Profile.Get", 1, codes.Unset)
+
+	if _, err := client.Contacts().Get(ctx, "8f1c6c2e-4d7a-4b3e-9a51-0c2d8e7f6a90"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get returned %v, want ErrNotFound", err)
+	}
+	check("Contacts.Get", 1, codes.Error)
+
+	for _, err := range client.Contacts().All(ctx, nil) {
+		if err != nil {
+			t.Fatalf("All returned %v", err)
+		}
+	}
+	check("Contacts.All", 2, codes.Unset)
+}
@@ -0,0 +1,143 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+)
+
+// ProgressFunc is called while an upload is sent. sent is the number of file bytes written so far,
+// total is the file size or -1 if it is unknown. When an upload is retried, sent starts at 0 again.
+type ProgressFunc func(sent, total int64)
+
+type progressKey struct{}
+
+// WithUploadProgress returns a context that reports the progress of uploads made with it to fn.
+func WithUploadProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func uploadProgress(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// newMultipartRequest creates a POST request that streams content as the "file" part of a
+// multipart/form-data body, followed by the given form fields.
+//
+// The body is produced by a goroutine writing into an io.Pipe, so the file is never buffered in
+// memory. If content implements io.ReadSeeker, it is rewound for every attempt and the request can
+// be retried; otherwise it is sent only once.
+//
+// finish must be called once the request is done. It waits for the writer goroutine to stop and
+// rewinds content to its original offset, so the caller can read it again.
+func newMultipartRequest(ctx context.Context, op operation, path, filename string, content io.Reader, fields [][2]string) (req *request, finish func(), err error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	progress := uploadProgress(ctx)
+
+	seeker, replayable := content.(io.ReadSeeker)
+	var start int64
+	total := int64(-1)
+	if replayable {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, nil, fmt.Errorf("failed to determine file offset: %w", err)
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			_, _ = seeker.Seek(start, io.SeekStart)
+			return nil, nil, fmt.Errorf("failed to determine file size: %w", err)
+		}
+		total = end - start
+	}
+
+	// The writer of the previous attempt may still be copying content after an early response,
+	// so it is stopped before the file is rewound.
+	var prev *io.PipeReader
+	var prevDone chan struct{}
+	stop := func() {
+		if prev != nil {
+			prev.CloseWithError(errAttemptDone)
+			<-prevDone
+		}
+	}
+	body := func() (io.Reader, error) {
+		stop()
+		if replayable {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("failed to rewind file: %w", err)
+			}
+		}
+
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		prev, prevDone = pr, done
+		go func() {
+			defer close(done)
+			writer := multipart.NewWriter(pw)
+			if err := writer.SetBoundary(boundary); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			part, err := writer.CreateFormFile("file", filename)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to create form file: %w", err))
+				return
+			}
+			src := content
+			if progress != nil {
+				src = &progressReader{r: content, total: total, fn: progress}
+			}
+			if _, err := io.Copy(part, src); err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to copy file content: %w", err))
+				return
+			}
+			for _, field := range fields {
+				if err := writer.WriteField(field[0], field[1]); err != nil {
+					pw.CloseWithError(fmt.Errorf("failed to write %s field: %w", field[0], err))
+					return
+				}
+			}
+			pw.CloseWithError(writer.Close())
+		}()
+		return pr, nil
+	}
+
+	finish = func() {
+		stop()
+		if replayable {
+			_, _ = seeker.Seek(start, io.SeekStart)
+		}
+	}
+	return &request{
+		op:     op,
+		method: "POST",
+		path:   path,
+		headers: map[string]string{
+			"Accept":       "application/json",
+			"Content-Type": "multipart/form-data; boundary=" + boundary,
+		},
+		body:    body,
+		oneShot: !replayable,
+	}, finish, nil
+}
+
+// errAttemptDone stops the body writer of an attempt that was superseded by a retry.
+var errAttemptDone = errors.New("upload attempt finished")
+
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
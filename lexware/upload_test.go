@@ -0,0 +1,83 @@
+package lexware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+func TestUpload(t *testing.T) {
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Reject") != "" {
+			// Answer before the body is read, leaving the writer blocked on the pipe.
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		received = append(received, string(content))
+		w.Write([]byte(`{"id":"file-id"}`))
+	}))
+	defer srv.Close()
+
+	var rejected error
+	client, err := NewClientWithConfig(Config{
+		BaseURL:   srv.URL,
+		APIKey:    "key",
+		RateLimit: -1,
+		Retry:     RetryPolicy{MaxAttempts: 1},
+		Middleware: []Middleware{InterceptRequest(func(req *Request) error {
+			return rejected
+		})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithCallOptions(context.Background(), Header("Content-Type", "text/plain"))
+
+	// The content starts at the current offset and is rewound to it afterwards.
+	content := strings.NewReader("skipped:" + strings.Repeat("x", 1<<20))
+	content.Seek(int64(len("skipped:")), io.SeekStart)
+	offset := func() int64 {
+		pos, _ := content.Seek(0, io.SeekCurrent)
+		return pos
+	}
+
+	result, err := client.Files().Upload(ctx, "receipt.pdf", content, types.FileUploadTypeVoucher)
+	if err != nil {
+		t.Fatalf("Upload returned %v", err)
+	}
+	if result.ID != "file-id" || len(received) != 1 || received[0] != strings.Repeat("x", 1<<20) {
+		t.Errorf("got result %+v and %d uploads", result, len(received))
+	}
+	if pos := offset(); pos != 8 {
+		t.Errorf("content is at offset %d after the upload, want 8", pos)
+	}
+
+	rejectCtx := WithCallOptions(ctx, Header("X-Reject", "1"))
+	if _, err := client.Files().Upload(rejectCtx, "receipt.pdf", content, types.FileUploadTypeVoucher); !errors.Is(err, ErrValidation) {
+		t.Errorf("rejected upload returned %v, want ErrValidation", err)
+	}
+	if pos := offset(); pos != 8 {
+		t.Errorf("content is at offset %d after a rejected upload, want 8", pos)
+	}
+
+	rejected = errors.New("rejected by middleware")
+	if _, err := client.Files().Upload(ctx, "receipt.pdf", content, types.FileUploadTypeVoucher); !errors.Is(err, rejected) {
+		t.Errorf("Upload returned %v, want the middleware error", err)
+	}
+	if pos := offset(); pos != 8 {
+		t.Errorf("content is at offset %d after an aborted upload, want 8", pos)
+	}
+}
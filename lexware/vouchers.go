@@ -1,13 +1,11 @@
 package lexware
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/http"
+	"iter"
 
 	"github.com/rasche-thalhofer/lexware-go/types"
 )
@@ -15,19 +13,15 @@ import (
 type vouchersClient struct{ client *Client }
 
 func (c *vouchersClient) Create(ctx context.Context, voucher *types.VoucherCreateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "POST", "/v1/vouchers", voucher)
-	if err != nil {
-		return nil, err
-	}
-	var result types.ActionResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-	return &result, nil
+	return c.client.doCreate(ctx, operation{name: "Vouchers.Create"}, "/v1/vouchers", voucher)
 }
 
 func (c *vouchersClient) Get(ctx context.Context, id string) (*types.Voucher, error) {
-	body, err := c.client.doRequest(ctx, "GET", "/v1/vouchers/"+id, nil)
+	path, err := idPath("/v1/vouchers", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Vouchers.Get", resourceID: id}, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +33,11 @@ func (c *vouchersClient) Get(ctx context.Context, id string) (*types.Voucher, er
 }
 
 func (c *vouchersClient) Update(ctx context.Context, id string, voucher *types.VoucherUpdateRequest) (*types.ActionResult, error) {
-	body, err := c.client.doRequest(ctx, "PUT", "/v1/vouchers/"+id, voucher)
+	path, err := idPath("/v1/vouchers", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.client.doRequest(ctx, operation{name: "Vouchers.Update", resourceID: id}, "PUT", path, voucher)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +50,9 @@ func (c *vouchersClient) Update(ctx context.Context, id string, voucher *types.V
 
 func (c *vouchersClient) List(ctx context.Context, opts *types.ListOptions, filter *types.VoucherFilterOptions) (*types.Page[types.Voucher], error) {
 	params := make(map[string]string)
-	addPagination(params, opts)
+	if err := addPagination(params, opts, "/v1/vouchers"); err != nil {
+		return nil, err
+	}
 	if filter != nil {
 		if filter.VoucherNumber != "" {
 			params["voucherNumber"] = filter.VoucherNumber
@@ -64,7 +64,7 @@ func (c *vouchersClient) List(ctx context.Context, opts *types.ListOptions, filt
 			params["contactId"] = filter.ContactID
 		}
 	}
-	body, err := c.client.doRequest(ctx, "GET", "/v1/vouchers"+buildQueryString(params), nil)
+	body, err := c.client.doRequest(ctx, listOperation("Vouchers.List", opts), "GET", "/v1/vouchers"+buildQueryString(params), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -75,43 +75,35 @@ func (c *vouchersClient) List(ctx context.Context, opts *types.ListOptions, filt
 	return &page, nil
 }
 
+func (c *vouchersClient) All(ctx context.Context, filter *types.VoucherFilterOptions) iter.Seq2[types.Voucher, error] {
+	return traceAll(c.client, ctx, "Vouchers.All", func(ctx context.Context) iter.Seq2[types.Voucher, error] {
+		return ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.Voucher], error) {
+			return c.List(ctx, opts, filter)
+		}, func(v types.Voucher) string { return v.ID })
+	})
+}
+
 func (c *vouchersClient) UploadFile(ctx context.Context, id string, filename string, content io.Reader) error {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-	part, err := writer.CreateFormFile("file", filename)
+	path, err := idPath("/v1/vouchers", id, "files")
 	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
+		return err
 	}
-	if _, err := io.Copy(part, content); err != nil {
-		return fmt.Errorf("failed to copy file content: %w", err)
-	}
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-	req, err := http.NewRequestWithContext(ctx, "POST", c.client.baseURL+"/v1/vouchers/"+id+"/files", &buf)
+	req, finish, err := newMultipartRequest(ctx, operation{name: "Vouchers.UploadFile", resourceID: id}, path, filename, content, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+c.client.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Accept", "application/json")
-	resp, err := c.client.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
-	}
-	return nil
+	defer finish()
+	_, err = c.client.do(ctx, req)
+	return err
 }
 
 type voucherListClient struct{ client *Client }
 
 func (c *voucherListClient) List(ctx context.Context, opts *types.ListOptions, filter *types.VoucherListFilterOptions) (*types.Page[types.VoucherListItem], error) {
 	params := make(map[string]string)
-	addPagination(params, opts)
+	if err := addPagination(params, opts, "/v1/voucherlist"); err != nil {
+		return nil, err
+	}
 	if filter != nil {
 		if filter.VoucherType != "" {
 			params["voucherType"] = string(filter.VoucherType)
@@ -148,7 +140,7 @@ func (c *voucherListClient) List(ctx context.Context, opts *types.ListOptions, f
 			params["updatedDateTo"] = filter.UpdatedDateTo
 		}
 	}
-	body, err := c.client.doRequest(ctx, "GET", "/v1/voucherlist"+buildQueryString(params), nil)
+	body, err := c.client.doRequest(ctx, listOperation("VoucherList.List", opts), "GET", "/v1/voucherlist"+buildQueryString(params), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -158,3 +150,11 @@ func (c *voucherListClient) List(ctx context.Context, opts *types.ListOptions, f
 	}
 	return &page, nil
 }
+
+func (c *voucherListClient) All(ctx context.Context, filter *types.VoucherListFilterOptions) iter.Seq2[types.VoucherListItem, error] {
+	return traceAll(c.client, ctx, "VoucherList.All", func(ctx context.Context) iter.Seq2[types.VoucherListItem, error] {
+		return ListAll(ctx, func(ctx context.Context, opts *types.ListOptions) (*types.Page[types.VoucherListItem], error) {
+			return c.List(ctx, opts, filter)
+		}, func(item types.VoucherListItem) string { return item.ID })
+	})
+}
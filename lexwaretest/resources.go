@@ -0,0 +1,352 @@
+package lexwaretest
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rasche-thalhofer/lexware-go/internal/uuid"
+)
+
+const (
+	firstCustomerNumber = 10000
+	firstVendorNumber   = 70000
+)
+
+func (s *Server) registerResources(mux *http.ServeMux) {
+	s.handle(mux, "POST /v1/contacts", s.createContact)
+	s.handle(mux, "GET /v1/contacts/{id}", s.getHandler("contacts"))
+	s.handle(mux, "PUT /v1/contacts/{id}", s.updateContact)
+	s.handle(mux, "GET /v1/contacts", s.listContacts)
+
+	s.handle(mux, "POST /v1/articles", s.createArticle)
+	s.handle(mux, "GET /v1/articles/{id}", s.getHandler("articles"))
+	s.handle(mux, "PUT /v1/articles/{id}", s.updateArticle)
+	s.handle(mux, "DELETE /v1/articles/{id}", s.deleteArticle)
+	s.handle(mux, "GET /v1/articles", s.listArticles)
+
+	s.handle(mux, "POST /v1/files", s.uploadFile)
+	s.handle(mux, "GET /v1/files/{id}", s.downloadFile)
+
+	s.handle(mux, "POST /v1/event-subscriptions", s.createEventSubscription)
+	s.handle(mux, "GET /v1/event-subscriptions/{id}", s.getEventSubscription)
+	s.handle(mux, "GET /v1/event-subscriptions", s.listEventSubscriptions)
+	s.handle(mux, "DELETE /v1/event-subscriptions/{id}", s.deleteEventSubscription)
+
+	s.handle(mux, "GET /v1/countries", func(*http.Request) (int, interface{}, error) {
+		return http.StatusOK, s.Countries, nil
+	})
+	s.handle(mux, "GET /v1/payment-conditions", func(*http.Request) (int, interface{}, error) {
+		return http.StatusOK, s.PaymentConditions, nil
+	})
+	s.handle(mux, "GET /v1/posting-categories", func(*http.Request) (int, interface{}, error) {
+		return http.StatusOK, s.PostingCategories, nil
+	})
+	s.handle(mux, "GET /v1/print-layouts", func(*http.Request) (int, interface{}, error) {
+		return http.StatusOK, s.PrintLayouts, nil
+	})
+	s.handle(mux, "GET /v1/profile", func(*http.Request) (int, interface{}, error) {
+		return http.StatusOK, s.Profile, nil
+	})
+
+	s.handle(mux, "GET /v1/recurring-templates/{id}", s.getHandler("recurring-templates"))
+	s.handle(mux, "GET /v1/recurring-templates", func(r *http.Request) (int, interface{}, error) {
+		page, err := paginate(r, s.list("recurring-templates", nil))
+		return http.StatusOK, page, err
+	})
+}
+
+// getHandler returns a handler serving single resources of a collection.
+func (s *Server) getHandler(collection string) handlerFunc {
+	return func(r *http.Request) (int, interface{}, error) {
+		obj, err := s.get(collection, r.PathValue("id"))
+		return http.StatusOK, obj, err
+	}
+}
+
+func (s *Server) createContact(r *http.Request) (int, interface{}, error) {
+	contact, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.validateContact(contact); err != nil {
+		return 0, nil, err
+	}
+	s.assignContactNumbers(contact, nil)
+	contact["archived"] = false
+	return http.StatusCreated, s.insert("contacts", contact), nil
+}
+
+func (s *Server) updateContact(r *http.Request) (int, interface{}, error) {
+	contact, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.validateContact(contact); err != nil {
+		return 0, nil, err
+	}
+	existing, err := s.get("contacts", r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	s.assignContactNumbers(contact, existing)
+	result, err := s.update("contacts", r.PathValue("id"), contact)
+	return http.StatusOK, result, err
+}
+
+func (s *Server) validateContact(contact object) error {
+	if err := require(contact, "roles"); err != nil {
+		return err
+	}
+	if field(contact, "company") == nil && field(contact, "person") == nil {
+		return validationError("person", "NOTNULL", "either company or person must be set")
+	}
+	if field(contact, "company") != nil && stringField(contact, "company", "name") == "" {
+		return validationError("company.name", "NOTNULL", "must not be null")
+	}
+	if field(contact, "person") != nil && stringField(contact, "person", "lastName") == "" {
+		return validationError("person.lastName", "NOTNULL", "must not be null")
+	}
+	return nil
+}
+
+// assignContactNumbers numbers new customer and vendor roles. Numbers of existing roles are kept.
+func (s *Server) assignContactNumbers(contact, existing object) {
+	for _, role := range []struct {
+		name  string
+		first int
+	}{{"customer", firstCustomerNumber}, {"vendor", firstVendorNumber}} {
+		r, ok := field(contact, "roles", role.name).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if number := intField(existing, "roles", role.name, "number"); number != 0 {
+			r["number"] = number
+			continue
+		}
+		r["number"] = role.first + s.numbers["contact."+role.name]
+		s.numbers["contact."+role.name]++
+	}
+}
+
+func (s *Server) listContacts(r *http.Request) (int, interface{}, error) {
+	query := r.URL.Query()
+	email := strings.ToLower(query.Get("email"))
+	name := strings.ToLower(query.Get("name"))
+	number, _ := strconv.Atoi(query.Get("number"))
+	contacts := s.list("contacts", func(c object) bool {
+		if email != "" && !containsString(field(c, "emailAddresses"), email) {
+			return false
+		}
+		if name != "" && !strings.Contains(strings.ToLower(contactName(c)), name) {
+			return false
+		}
+		if number != 0 && intField(c, "roles", "customer", "number") != number && intField(c, "roles", "vendor", "number") != number {
+			return false
+		}
+		if query.Get("customer") == "true" && field(c, "roles", "customer") == nil {
+			return false
+		}
+		if query.Get("vendor") == "true" && field(c, "roles", "vendor") == nil {
+			return false
+		}
+		return true
+	})
+	if err := sortItems(r, contacts, map[string]func(object) interface{}{
+		"name": func(c object) interface{} { return contactName(c) },
+	}); err != nil {
+		return 0, nil, err
+	}
+	page, err := paginate(r, contacts)
+	return http.StatusOK, page, err
+}
+
+// contactName returns the company name or the full name of a contact.
+func contactName(c object) string {
+	if name := stringField(c, "company", "name"); name != "" {
+		return name
+	}
+	return strings.TrimSpace(stringField(c, "person", "firstName") + " " + stringField(c, "person", "lastName"))
+}
+
+// containsString reports whether any string within v contains substr, ignoring case.
+func containsString(v interface{}, substr string) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, value := range v {
+			if containsString(value, substr) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if containsString(value, substr) {
+				return true
+			}
+		}
+	case string:
+		return strings.Contains(strings.ToLower(v), substr)
+	}
+	return false
+}
+
+func (s *Server) createArticle(r *http.Request) (int, interface{}, error) {
+	article, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := validateArticle(article); err != nil {
+		return 0, nil, err
+	}
+	article["archived"] = false
+	return http.StatusCreated, s.insert("articles", article), nil
+}
+
+func (s *Server) updateArticle(r *http.Request) (int, interface{}, error) {
+	article, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := validateArticle(article); err != nil {
+		return 0, nil, err
+	}
+	result, err := s.update("articles", r.PathValue("id"), article)
+	return http.StatusOK, result, err
+}
+
+func validateArticle(article object) error {
+	if err := require(article, "title", "type", "unitName", "price"); err != nil {
+		return err
+	}
+	if t := stringField(article, "type"); t != "PRODUCT" && t != "SERVICE" {
+		return validationError("type", "INVALID", "must be PRODUCT or SERVICE")
+	}
+	return nil
+}
+
+func (s *Server) deleteArticle(r *http.Request) (int, interface{}, error) {
+	if err := s.remove("articles", r.PathValue("id")); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) listArticles(r *http.Request) (int, interface{}, error) {
+	query := r.URL.Query()
+	articles := s.list("articles", func(a object) bool {
+		return (query.Get("articleNumber") == "" || stringField(a, "articleNumber") == query.Get("articleNumber")) &&
+			(query.Get("gtin") == "" || stringField(a, "gtin") == query.Get("gtin")) &&
+			(query.Get("type") == "" || stringField(a, "type") == query.Get("type"))
+	})
+	page, err := paginate(r, articles)
+	return http.StatusOK, page, err
+}
+
+func (s *Server) uploadFile(r *http.Request) (int, interface{}, error) {
+	fields, f, err := readMultipart(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if fields["type"] != "voucher" {
+		return 0, nil, validationError("type", "INVALID", "must be voucher")
+	}
+	id := s.storeFile(f)
+	return http.StatusAccepted, object{"id": id}, nil
+}
+
+func (s *Server) downloadFile(r *http.Request) (int, interface{}, error) {
+	f, ok := s.files[r.PathValue("id")]
+	if !ok {
+		return 0, nil, notFound()
+	}
+	return http.StatusOK, rawResponse{contentType: f.contentType, data: f.data}, nil
+}
+
+func (s *Server) storeFile(f file) string {
+	id := uuid.New()
+	s.files[id] = f
+	return id
+}
+
+// readMultipart reads the form fields and the file of a multipart upload.
+func readMultipart(r *http.Request) (map[string]string, file, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return nil, file{}, errorf(http.StatusUnsupportedMediaType, "Expected a multipart/form-data request.")
+	}
+	fields := make(map[string]string)
+	var f file
+	var found bool
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, file{}, errorf(http.StatusBadRequest, "Malformed multipart request body.")
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, file{}, errorf(http.StatusBadRequest, "Malformed multipart request body.")
+		}
+		if part.FormName() == "file" {
+			f = file{contentType: part.Header.Get("Content-Type"), data: data}
+			if f.contentType == "" {
+				f.contentType = "application/octet-stream"
+			}
+			found = true
+			continue
+		}
+		fields[part.FormName()] = string(data)
+	}
+	if !found {
+		return nil, file{}, validationError("file", "NOTNULL", "must not be null")
+	}
+	return fields, f, nil
+}
+
+func (s *Server) createEventSubscription(r *http.Request) (int, interface{}, error) {
+	subscription, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := require(subscription, "eventType", "callbackUrl"); err != nil {
+		return 0, nil, err
+	}
+	for _, existing := range s.list("event-subscriptions", nil) {
+		if existing["eventType"] == subscription["eventType"] {
+			return 0, nil, errorf(http.StatusConflict, "An event subscription for %s already exists.", subscription["eventType"])
+		}
+	}
+	result := s.insert("event-subscriptions", subscription)
+	// Event subscriptions are identified by subscriptionId and have no version.
+	subscription["subscriptionId"] = subscription["id"]
+	delete(subscription, "id")
+	delete(subscription, "version")
+	delete(subscription, "updatedDate")
+	return http.StatusCreated, result, nil
+}
+
+func (s *Server) getEventSubscription(r *http.Request) (int, interface{}, error) {
+	subscription, err := s.get("event-subscriptions", r.PathValue("id"))
+	return http.StatusOK, subscription, err
+}
+
+func (s *Server) listEventSubscriptions(*http.Request) (int, interface{}, error) {
+	subscriptions := s.list("event-subscriptions", nil)
+	if subscriptions == nil {
+		subscriptions = []object{}
+	}
+	return http.StatusOK, object{"content": subscriptions}, nil
+}
+
+func (s *Server) deleteEventSubscription(r *http.Request) (int, interface{}, error) {
+	if err := s.remove("event-subscriptions", r.PathValue("id")); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
@@ -0,0 +1,525 @@
+// Package lexwaretest provides an in-memory fake of the Lexware API for integration tests.
+//
+//	srv := lexwaretest.NewServer()
+//	defer srv.Close()
+//
+//	client, err := lexware.NewClientWithConfig(srv.Config())
+//
+// The server implements all endpoints covered by lexware.Client and mimics the behaviour of the
+// real API closely enough for most tests:
+//
+//   - Created resources get an ID, version 1 and creation dates. Updates must send the current
+//     version and are answered with 409 Conflict otherwise.
+//   - Sales vouchers created with finalize=true are open and numbered per voucher type, e.g.
+//     "RE0001" for the first invoice. Drafts have no number and their documents can't be
+//     rendered (406 Not Acceptable). Dunnings are always drafts but can be rendered.
+//   - Pursuing a voucher links it and its preceding voucher through relatedVouchers. Only
+//     finalized vouchers can be pursued.
+//   - Totals and tax amounts of sales vouchers are calculated from their line items.
+//   - Lists are paginated with the page and size query parameters, and the voucherlist combines
+//     sales vouchers and bookkeeping vouchers. Contacts and the voucherlist can be sorted with the
+//     sort query parameter.
+//   - Invalid request bodies are answered with 406 Not Acceptable like by the real API, invalid
+//     query parameters and malformed bodies with 400 Bad Request.
+//   - Requests without an API key are answered with 401 Unauthorized. Any key is accepted.
+//
+// Errors use the JSON format of the real API, so the client decodes them into *lexware.APIError.
+package lexwaretest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/internal/uuid"
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// APIKey is the API key used by Server.Config. The server accepts any non-empty key.
+const APIKey = "lexwaretest"
+
+const (
+	defaultPageSize = 25
+	maxPageSize     = 250
+)
+
+// Server is a fake Lexware API backed by in-memory storage. It is safe for concurrent use.
+//
+// The reference data fields are served by the corresponding endpoints. They are filled with
+// defaults by NewServer and may be changed before the first request.
+type Server struct {
+	*httptest.Server
+
+	Profile           types.Profile
+	Countries         []types.Country
+	PaymentConditions []types.PaymentCondition
+	PostingCategories []types.PostingCategory
+	PrintLayouts      []types.PrintLayout
+
+	mu      sync.Mutex
+	stores  map[string]*store
+	files   map[string]file
+	numbers map[string]int
+}
+
+// object is a resource as stored by the server, i.e. its decoded JSON representation.
+type object = map[string]interface{}
+
+// store holds the resources of one collection in creation order.
+type store struct {
+	ids   []string
+	items map[string]object
+}
+
+type file struct {
+	contentType string
+	data        []byte
+}
+
+// NewServer starts a new Server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		stores:  make(map[string]*store),
+		files:   make(map[string]file),
+		numbers: make(map[string]int),
+	}
+	organizationID := uuid.New()
+	s.Profile = types.Profile{
+		OrganizationID: organizationID,
+		CompanyName:    "Lexware Test GmbH",
+		Created:        &types.LexwareDate{Year: 2024, Month: 1, Day: 1},
+		ConnectionID:   uuid.New(),
+		TaxType:        string(types.TaxTypeNet),
+	}
+	s.Countries = []types.Country{
+		{CountryCode: "DE", CountryNameDE: "Deutschland", CountryNameEN: "Germany", TaxClassification: string(types.TaxClassificationDomestic)},
+		{CountryCode: "AT", CountryNameDE: "Österreich", CountryNameEN: "Austria", TaxClassification: string(types.TaxClassificationIntraCommunity)},
+		{CountryCode: "FR", CountryNameDE: "Frankreich", CountryNameEN: "France", TaxClassification: string(types.TaxClassificationIntraCommunity)},
+		{CountryCode: "CH", CountryNameDE: "Schweiz", CountryNameEN: "Switzerland", TaxClassification: string(types.TaxClassificationThirdPartyCountry)},
+		{CountryCode: "US", CountryNameDE: "Vereinigte Staaten von Amerika", CountryNameEN: "United States of America", TaxClassification: string(types.TaxClassificationThirdPartyCountry)},
+	}
+	s.PaymentConditions = []types.PaymentCondition{
+		{ID: uuid.New(), OrganizationID: organizationID, PaymentTermLabelTemplate: "Zahlbar sofort, rein netto"},
+		{ID: uuid.New(), OrganizationID: organizationID, PaymentTermLabelTemplate: "Zahlbar innerhalb von 14 Tagen", PaymentTermDuration: 14},
+		{
+			ID:                        uuid.New(),
+			OrganizationID:            organizationID,
+			PaymentTermLabelTemplate:  "Zahlbar innerhalb von 30 Tagen, 2 % Skonto bei Zahlung innerhalb von 10 Tagen",
+			PaymentTermDuration:       30,
+			PaymentDiscountConditions: &types.PaymentDiscountConditions{DiscountPercentage: 2, DiscountRange: 10},
+		},
+	}
+	s.PostingCategories = []types.PostingCategory{
+		{ID: uuid.New(), Name: "Einnahmen", Type: "income", GroupName: "Einnahmen"},
+		{ID: uuid.New(), Name: "Dienstleistung", Type: "income", GroupName: "Einnahmen", SplitAllowed: true},
+		{ID: uuid.New(), Name: "Büromaterial", Type: "outgo", GroupName: "Ausgaben", SplitAllowed: true},
+		{ID: uuid.New(), Name: "Reisekosten", Type: "outgo", GroupName: "Ausgaben", ContactRequired: true},
+	}
+	s.PrintLayouts = []types.PrintLayout{
+		{ID: uuid.New(), Name: "Standard", IsDefault: true},
+		{ID: uuid.New(), Name: "Modern"},
+	}
+
+	mux := http.NewServeMux()
+	s.registerResources(mux)
+	s.registerVouchers(mux)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config returns a client configuration for the server with rate limiting disabled.
+func (s *Server) Config() lexware.Config {
+	return lexware.Config{
+		BaseURL:   s.URL,
+		APIKey:    APIKey,
+		RateLimit: -1,
+	}
+}
+
+// handlerFunc handles a request while the server is locked. It returns the status code and the
+// value to send as JSON, or an error. Values of type rawResponse are sent as is.
+type handlerFunc func(r *http.Request) (int, interface{}, error)
+
+// rawResponse is a non-JSON response body, e.g. a rendered document.
+type rawResponse struct {
+	contentType string
+	data        []byte
+}
+
+// handle registers a handler with authentication, locking and error handling.
+func (s *Server) handle(mux *http.ServeMux, pattern string, h handlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer")) == "" {
+			writeError(w, r, errorf(http.StatusUnauthorized, "Unauthorized"))
+			return
+		}
+
+		// Handlers return the stored objects themselves, so they are encoded before other
+		// requests may change them.
+		s.mu.Lock()
+		status, v, err := h(r)
+		var data []byte
+		if _, raw := v.(rawResponse); err == nil && v != nil && !raw {
+			data, err = json.Marshal(v)
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if raw, ok := v.(rawResponse); ok {
+			w.Header().Set("Content-Type", raw.contentType)
+			w.WriteHeader(status)
+			_, _ = w.Write(raw.data)
+			return
+		}
+		if v == nil {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(append(data, '\n'))
+	})
+}
+
+// apiError is an error response in the format of the Lexware API.
+type apiError struct {
+	status  int
+	message string
+	details []errorDetail
+}
+
+type errorDetail struct {
+	Violation string `json:"violation"`
+	Field     string `json:"field"`
+	Message   string `json:"message"`
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func errorf(status int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// validationError reports a missing or invalid field of a request body with 406 Not Acceptable,
+// which the real API uses for most validation failures.
+func validationError(field, violation, message string) *apiError {
+	return &apiError{
+		status:  http.StatusNotAcceptable,
+		message: "Validation failed for request. Please see details list for specific causes.",
+		details: []errorDetail{{Violation: violation, Field: field, Message: message}},
+	}
+}
+
+// queryError reports an invalid query parameter with 400 Bad Request.
+func queryError(param, violation, message string) *apiError {
+	err := validationError(param, violation, message)
+	err.status = http.StatusBadRequest
+	return err
+}
+
+func notFound() *apiError {
+	return errorf(http.StatusNotFound, "Requested resource does not exist.")
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr, ok := err.(*apiError)
+	if !ok {
+		apiErr = errorf(http.StatusInternalServerError, "%s", err.Error())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Timestamp string        `json:"timestamp"`
+		Status    int           `json:"status"`
+		Error     string        `json:"error"`
+		Path      string        `json:"path"`
+		TraceID   string        `json:"traceId"`
+		Message   string        `json:"message"`
+		Details   []errorDetail `json:"details,omitempty"`
+	}{
+		Timestamp: now(),
+		Status:    apiErr.status,
+		Error:     http.StatusText(apiErr.status),
+		Path:      r.URL.Path,
+		TraceID:   uuid.New(),
+		Message:   apiErr.message,
+		Details:   apiErr.details,
+	})
+}
+
+// collection returns the store of a collection, creating it if necessary.
+func (s *Server) collection(name string) *store {
+	st, ok := s.stores[name]
+	if !ok {
+		st = &store{items: make(map[string]object)}
+		s.stores[name] = st
+	}
+	return st
+}
+
+// insert stores a new resource, setting its ID, organization, version and dates, and returns
+// the action result for it.
+func (s *Server) insert(collection string, obj object) object {
+	id := uuid.New()
+	date := now()
+	obj["id"] = id
+	obj["organizationId"] = s.Profile.OrganizationID
+	obj["createdDate"] = date
+	obj["updatedDate"] = date
+	obj["version"] = 1
+
+	st := s.collection(collection)
+	st.ids = append(st.ids, id)
+	st.items[id] = obj
+	return s.actionResult(collection, obj)
+}
+
+// serverFields are the fields of a resource maintained by the server, which clients can't change.
+var serverFields = map[string]bool{
+	"id":             true,
+	"organizationId": true,
+	"createdDate":    true,
+	"updatedDate":    true,
+	"version":        true,
+	"archived":       true,
+	"voucherStatus":  true,
+	"files":          true,
+}
+
+// update replaces the fields of a stored resource after checking the version sent by the client.
+func (s *Server) update(collection, id string, fields object) (object, error) {
+	obj, ok := s.collection(collection).items[id]
+	if !ok {
+		return nil, notFound()
+	}
+	if version, _ := fields["version"].(float64); int(version) != intField(obj, "version") {
+		return nil, errorf(http.StatusConflict,
+			"The version %d of the resource is outdated, the current version is %d.", int(version), intField(obj, "version"))
+	}
+	for key := range obj {
+		if !serverFields[key] {
+			delete(obj, key)
+		}
+	}
+	for key, value := range fields {
+		if !serverFields[key] {
+			obj[key] = value
+		}
+	}
+	obj["version"] = intField(obj, "version") + 1
+	obj["updatedDate"] = now()
+	return s.actionResult(collection, obj), nil
+}
+
+// remove deletes a stored resource.
+func (s *Server) remove(collection, id string) error {
+	st := s.collection(collection)
+	if _, ok := st.items[id]; !ok {
+		return notFound()
+	}
+	delete(st.items, id)
+	for i, existing := range st.ids {
+		if existing == id {
+			st.ids = append(st.ids[:i], st.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Server) get(collection, id string) (object, error) {
+	obj, ok := s.collection(collection).items[id]
+	if !ok {
+		return nil, notFound()
+	}
+	return obj, nil
+}
+
+func (s *Server) actionResult(collection string, obj object) object {
+	return object{
+		"id":          obj["id"],
+		"resourceUri": s.URL + "/v1/" + collection + "/" + obj["id"].(string),
+		"createdDate": obj["createdDate"],
+		"updatedDate": obj["updatedDate"],
+		"version":     obj["version"],
+	}
+}
+
+// list returns the resources of a collection matching filter in creation order.
+func (s *Server) list(collection string, filter func(object) bool) []object {
+	st := s.collection(collection)
+	var items []object
+	for _, id := range st.ids {
+		if obj := st.items[id]; filter == nil || filter(obj) {
+			items = append(items, obj)
+		}
+	}
+	return items
+}
+
+// paginate returns the page of items requested by the page and size query parameters.
+func paginate[T any](r *http.Request, items []T) (object, error) {
+	page, err := queryInt(r, "page", 0)
+	if err != nil {
+		return nil, err
+	}
+	size, err := queryInt(r, "size", defaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+	if page < 0 {
+		return nil, queryError("page", "INVALID", "must be greater than or equal to 0")
+	}
+	if size < 1 || size > maxPageSize {
+		return nil, queryError("size", "INVALID", fmt.Sprintf("must be between 1 and %d", maxPageSize))
+	}
+
+	totalPages := (len(items) + size - 1) / size
+	content := []T{}
+	if start := page * size; start < len(items) {
+		content = items[start:min(start+size, len(items))]
+	}
+	return object{
+		"content":          content,
+		"first":            page == 0,
+		"last":             page >= totalPages-1,
+		"totalPages":       totalPages,
+		"totalElements":    len(items),
+		"numberOfElements": len(content),
+		"size":             size,
+		"number":           page,
+	}, nil
+}
+
+// sortItems sorts items by the sort query parameter, e.g. "voucherDate,DESC". keys maps the
+// fields the endpoint can be sorted by to the string or number compared.
+func sortItems(r *http.Request, items []object, keys map[string]func(object) interface{}) error {
+	param := r.URL.Query().Get("sort")
+	if param == "" {
+		return nil
+	}
+	name, direction, _ := strings.Cut(param, ",")
+	key, ok := keys[name]
+	if !ok {
+		return queryError("sort", "INVALID", fmt.Sprintf("can't sort by %s", name))
+	}
+	desc := false
+	switch strings.ToUpper(direction) {
+	case "", "ASC":
+	case "DESC":
+		desc = true
+	default:
+		return queryError("sort", "INVALID", "direction must be ASC or DESC")
+	}
+	slices.SortStableFunc(items, func(a, b object) int {
+		var c int
+		switch x := key(a).(type) {
+		case float64:
+			y, _ := key(b).(float64)
+			c = cmp.Compare(x, y)
+		default:
+			c = strings.Compare(fmt.Sprint(x), fmt.Sprint(key(b)))
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+	return nil
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, queryError(name, "INVALID", "must be a number")
+	}
+	return n, nil
+}
+
+// decode decodes a JSON request body into an object.
+func decode(r *http.Request) (object, error) {
+	var obj object
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil || obj == nil {
+		return nil, errorf(http.StatusBadRequest, "Malformed JSON request body.")
+	}
+	return obj, nil
+}
+
+// field returns the value at path within obj, or nil if it doesn't exist.
+func field(obj object, path ...string) interface{} {
+	var v interface{} = obj
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func stringField(obj object, path ...string) string {
+	s, _ := field(obj, path...).(string)
+	return s
+}
+
+func floatField(obj object, path ...string) float64 {
+	switch v := field(obj, path...).(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return 0
+}
+
+func intField(obj object, path ...string) int {
+	return int(floatField(obj, path...))
+}
+
+func boolField(obj object, path ...string) bool {
+	b, _ := field(obj, path...).(bool)
+	return b
+}
+
+// require returns a validation error if a field is missing.
+func require(obj object, fields ...string) error {
+	for _, f := range fields {
+		if v, ok := obj[f]; !ok || v == nil || v == "" {
+			return validationError(f, "NOTNULL", "must not be null")
+		}
+	}
+	return nil
+}
+
+// datePart returns the date of an RFC 3339 timestamp, e.g. "2024-03-01".
+func datePart(s string) string {
+	if len(s) > 10 {
+		return s[:10]
+	}
+	return s
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+func now() string {
+	return time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+}
@@ -0,0 +1,242 @@
+package lexwaretest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/lexwaretest"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+func newClient(t *testing.T) *lexware.Client {
+	t.Helper()
+	server := lexwaretest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := lexware.NewClientWithConfig(server.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func newInvoice(name string, netAmount float64) *types.InvoiceCreateRequest {
+	return &types.InvoiceCreateRequest{
+		VoucherDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Address:     &types.Address{Name: name, CountryCode: "DE"},
+		LineItems: []types.LineItem{{
+			Type:      "custom",
+			Name:      "Consulting",
+			Quantity:  2,
+			UnitName:  "hours",
+			UnitPrice: &types.UnitPrice{Currency: "EUR", NetAmount: netAmount, TaxRatePercentage: 19},
+		}},
+		TotalPrice:    &types.TotalPrice{Currency: "EUR"},
+		TaxConditions: &types.TaxConditions{TaxType: "net"},
+	}
+}
+
+func newContact(name string) *types.ContactCreateRequest {
+	return &types.ContactCreateRequest{
+		Roles:   &types.ContactRoles{Customer: &types.CustomerRole{}},
+		Company: &types.Company{Name: name},
+	}
+}
+
+func TestCreateGet(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	result, err := client.Invoices().Create(ctx, newInvoice("Doe Ltd", 100), false)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if result.ID == "" || result.Version != 1 || result.CreatedDate.IsZero() {
+		t.Errorf("Create() = %+v, want an ID, version 1 and a creation date", result)
+	}
+
+	invoice, err := client.Invoices().Get(ctx, result.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if invoice.ID != result.ID || invoice.Address.Name != "Doe Ltd" || invoice.VoucherStatus != types.VoucherStatusDraft {
+		t.Errorf("Get() = %s %q %s, want %s %q draft", invoice.ID, invoice.Address.Name, invoice.VoucherStatus, result.ID, "Doe Ltd")
+	}
+	if got := invoice.TotalPrice; got == nil || got.TotalNetAmount != 200 || got.TotalGrossAmount != 238 {
+		t.Errorf("TotalPrice = %+v, want 200 net and 238 gross", got)
+	}
+
+	_, err = client.Invoices().Get(ctx, "8a4c4b3e-1234-4abc-9def-0123456789ab")
+	if !errors.Is(err, lexware.ErrNotFound) {
+		t.Errorf("Get() of an unknown invoice = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	client := newClient(t)
+	invoice := newInvoice("Doe Ltd", 100)
+	invoice.LineItems = nil
+
+	_, err := client.Invoices().Create(context.Background(), invoice, false)
+	var apiErr *lexware.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotAcceptable {
+		t.Fatalf("Create() without line items = %v, want 406", err)
+	}
+	if !errors.Is(err, lexware.ErrValidation) {
+		t.Error("errors.Is(err, ErrValidation) = false")
+	}
+	if len(apiErr.Issues) != 1 || apiErr.Issues[0].Source != "lineItems" {
+		t.Errorf("Issues = %+v, want one for lineItems", apiErr.Issues)
+	}
+}
+
+func TestFinalizeNumbering(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		finalize   bool
+		wantStatus types.VoucherStatus
+		wantNumber string
+	}{
+		{finalize: true, wantStatus: types.VoucherStatusOpen, wantNumber: "RE0001"},
+		{finalize: false, wantStatus: types.VoucherStatusDraft, wantNumber: ""},
+		{finalize: true, wantStatus: types.VoucherStatusOpen, wantNumber: "RE0002"},
+	}
+	for i, tt := range tests {
+		result, err := client.Invoices().Create(ctx, newInvoice("Doe Ltd", 100), tt.finalize)
+		if err != nil {
+			t.Fatalf("invoice %d: Create() error = %v", i, err)
+		}
+		invoice, err := client.Invoices().Get(ctx, result.ID)
+		if err != nil {
+			t.Fatalf("invoice %d: Get() error = %v", i, err)
+		}
+		if invoice.VoucherStatus != tt.wantStatus || invoice.VoucherNumber != tt.wantNumber {
+			t.Errorf("invoice %d: %s %q, want %s %q", i, invoice.VoucherStatus, invoice.VoucherNumber, tt.wantStatus, tt.wantNumber)
+		}
+	}
+
+	// Voucher types are numbered independently.
+	invoice := newInvoice("Doe Ltd", 100)
+	expiration := invoice.VoucherDate.AddDate(0, 1, 0)
+	quotation := &types.QuotationCreateRequest{
+		VoucherDate:    invoice.VoucherDate,
+		ExpirationDate: &expiration,
+		Address:        invoice.Address,
+		LineItems:      invoice.LineItems,
+		TotalPrice:     invoice.TotalPrice,
+		TaxConditions:  invoice.TaxConditions,
+	}
+	result, err := client.Quotations().Create(ctx, quotation, true)
+	if err != nil {
+		t.Fatalf("Quotations().Create() error = %v", err)
+	}
+	q, err := client.Quotations().Get(ctx, result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.VoucherNumber != "AG0001" {
+		t.Errorf("quotation number = %q, want AG0001", q.VoucherNumber)
+	}
+}
+
+func TestUpdateConflict(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	result, err := client.Contacts().Create(ctx, newContact("Doe Ltd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := &types.ContactUpdateRequest{Version: 1, Roles: &types.ContactRoles{Customer: &types.CustomerRole{}}, Company: &types.Company{Name: "Doe GmbH"}}
+	updated, err := client.Contacts().Update(ctx, result.ID, update)
+	if err != nil {
+		t.Fatalf("Update() with the current version = %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("version after Update() = %d, want 2", updated.Version)
+	}
+
+	// The same update again sends the outdated version 1.
+	_, err = client.Contacts().Update(ctx, result.ID, update)
+	if !errors.Is(err, lexware.ErrConflict) {
+		t.Fatalf("Update() with an outdated version = %v, want ErrConflict", err)
+	}
+	contact, err := client.Contacts().Get(ctx, result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contact.Version != 2 || contact.Company.Name != "Doe GmbH" {
+		t.Errorf("contact after the conflict = version %d %q, want version 2 %q", contact.Version, contact.Company.Name, "Doe GmbH")
+	}
+}
+
+func TestUpload(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+	content := "%PDF-1.4 receipt"
+
+	upload, err := client.Files().Upload(ctx, "receipt.pdf", strings.NewReader(content), types.FileUploadType("voucher"))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	body, err := client.Files().Download(ctx, upload.ID)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("downloaded %q, want %q", data, content)
+	}
+
+	_, err = client.Files().Upload(ctx, "receipt.pdf", strings.NewReader(content), types.FileUploadType("invoice"))
+	if !errors.Is(err, lexware.ErrValidation) {
+		t.Errorf("Upload() with an invalid type = %v, want ErrValidation", err)
+	}
+}
+
+func TestSorting(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+	for _, name := range []string{"Bravo", "Charlie", "Alpha"} {
+		if _, err := client.Contacts().Create(ctx, newContact(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts *types.ListOptions
+		want []string
+	}{
+		{name: "creation order", opts: nil, want: []string{"Bravo", "Charlie", "Alpha"}},
+		{name: "ascending", opts: &types.ListOptions{Sort: types.SortByName}, want: []string{"Alpha", "Bravo", "Charlie"}},
+		{name: "descending", opts: &types.ListOptions{Sort: types.SortByName, Direction: types.SortDesc}, want: []string{"Charlie", "Bravo", "Alpha"}},
+		{name: "paginated", opts: &types.ListOptions{Page: 1, Size: 2, Sort: types.SortByName}, want: []string{"Charlie"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := client.Contacts().List(ctx, tt.opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range page.Content {
+				got = append(got, c.Company.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("contacts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,480 @@
+package lexwaretest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+// salesVoucherKind describes one type of sales voucher.
+type salesVoucherKind struct {
+	collection  string
+	voucherType types.VoucherType
+	// prefix starts the numbers of finalized vouchers.
+	prefix string
+	// readOnly kinds can't be created through the API.
+	readOnly bool
+}
+
+const voucherTypeDunning types.VoucherType = "dunning"
+
+var salesVoucherKinds = []salesVoucherKind{
+	{collection: "quotations", voucherType: types.VoucherTypeQuotation, prefix: "AG"},
+	{collection: "invoices", voucherType: types.VoucherTypeInvoice, prefix: "RE"},
+	{collection: "credit-notes", voucherType: types.VoucherTypeCreditNote, prefix: "GS"},
+	{collection: "delivery-notes", voucherType: types.VoucherTypeDeliveryNote, prefix: "LS"},
+	{collection: "order-confirmations", voucherType: types.VoucherTypeOrderConfirmation, prefix: "AB"},
+	{collection: "dunnings", voucherType: voucherTypeDunning},
+	{collection: "down-payment-invoices", voucherType: types.VoucherTypeDownPaymentInvoice, prefix: "AR", readOnly: true},
+}
+
+// bookkeepingVoucherTypes lists the types of vouchers accepted by the vouchers endpoint.
+var bookkeepingVoucherTypes = []types.VoucherType{
+	types.VoucherTypeSalesInvoice,
+	types.VoucherTypeSalesCreditNote,
+	types.VoucherTypePurchaseInvoice,
+	types.VoucherTypePurchaseCreditNote,
+}
+
+func (s *Server) registerVouchers(mux *http.ServeMux) {
+	for _, kind := range salesVoucherKinds {
+		if !kind.readOnly {
+			s.handle(mux, "POST /v1/"+kind.collection, s.createSalesVoucher(kind))
+		}
+		s.handle(mux, "GET /v1/"+kind.collection+"/{id}", s.getHandler(kind.collection))
+		s.handle(mux, "GET /v1/"+kind.collection+"/{id}/document", s.renderDocument(kind, false))
+	}
+	s.handle(mux, "GET /v1/invoices/{id}/file", s.renderDocument(salesVoucherKinds[1], true))
+
+	s.handle(mux, "POST /v1/vouchers", s.createVoucher)
+	s.handle(mux, "GET /v1/vouchers/{id}", s.getHandler("vouchers"))
+	s.handle(mux, "PUT /v1/vouchers/{id}", s.updateVoucher)
+	s.handle(mux, "GET /v1/vouchers", s.listVouchers)
+	s.handle(mux, "POST /v1/vouchers/{id}/files", s.uploadVoucherFile)
+
+	s.handle(mux, "GET /v1/voucherlist", s.listVoucherList)
+	s.handle(mux, "GET /v1/payments/{id}", s.getPayment)
+}
+
+func (s *Server) createSalesVoucher(kind salesVoucherKind) handlerFunc {
+	return func(r *http.Request) (int, interface{}, error) {
+		voucher, err := decode(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := s.validateSalesVoucher(kind, voucher); err != nil {
+			return 0, nil, err
+		}
+
+		var preceding object
+		var precedingKind salesVoucherKind
+		if id := r.URL.Query().Get("precedingSalesVoucherId"); id != "" {
+			if preceding, precedingKind, err = s.findSalesVoucher(id); err != nil {
+				return 0, nil, err
+			}
+			if stringField(preceding, "voucherStatus") == string(types.VoucherStatusDraft) {
+				return 0, nil, errorf(http.StatusNotAcceptable, "The preceding sales voucher %s is a draft and can't be pursued.", id)
+			}
+			if kind.voucherType == voucherTypeDunning && precedingKind.voucherType != types.VoucherTypeInvoice {
+				return 0, nil, validationError("precedingSalesVoucherId", "INVALID", "dunnings can only be created for invoices")
+			}
+		} else if kind.voucherType == voucherTypeDunning {
+			return 0, nil, validationError("precedingSalesVoucherId", "NOTNULL", "must not be null")
+		}
+
+		calculateTotals(voucher)
+		if _, ok := voucher["language"]; !ok {
+			voucher["language"] = "de"
+		}
+		voucher["archived"] = false
+		voucher["voucherStatus"] = string(types.VoucherStatusDraft)
+		if r.URL.Query().Get("finalize") == "true" && kind.prefix != "" {
+			voucher["voucherStatus"] = string(types.VoucherStatusOpen)
+			s.numbers[kind.collection]++
+			voucher["voucherNumber"] = fmt.Sprintf("%s%04d", kind.prefix, s.numbers[kind.collection])
+		}
+
+		result := s.insert(kind.collection, voucher)
+		if preceding != nil {
+			appendRelatedVoucher(voucher, preceding, precedingKind)
+			appendRelatedVoucher(preceding, voucher, kind)
+		}
+		return http.StatusCreated, result, nil
+	}
+}
+
+func (s *Server) validateSalesVoucher(kind salesVoucherKind, voucher object) error {
+	required := []string{"voucherDate", "address", "lineItems", "taxConditions"}
+	if kind.voucherType == voucherTypeDunning {
+		required = []string{"voucherDate", "address", "taxConditions"}
+	}
+	if err := require(voucher, required...); err != nil {
+		return err
+	}
+	if stringField(voucher, "taxConditions", "taxType") == "" {
+		return validationError("taxConditions.taxType", "NOTNULL", "must not be null")
+	}
+
+	if contactID := stringField(voucher, "address", "contactId"); contactID != "" {
+		contact, ok := s.collection("contacts").items[contactID]
+		if !ok {
+			return validationError("address.contactId", "INVALID", "contact does not exist")
+		}
+		if stringField(voucher, "address", "name") == "" {
+			voucher["address"].(map[string]interface{})["name"] = contactName(contact)
+		}
+	} else if stringField(voucher, "address", "name") == "" {
+		return validationError("address.name", "NOTNULL", "must not be null")
+	}
+
+	items, _ := voucher["lineItems"].([]interface{})
+	if kind.voucherType != voucherTypeDunning && len(items) == 0 {
+		return validationError("lineItems", "NOTEMPTY", "must not be empty")
+	}
+	for i, item := range items {
+		li, ok := item.(map[string]interface{})
+		if !ok || stringField(li, "name") == "" {
+			return validationError(fmt.Sprintf("lineItems[%d].name", i), "NOTNULL", "must not be null")
+		}
+		if stringField(li, "type") != string(types.LineItemTypeText) && field(li, "unitPrice") == nil {
+			return validationError(fmt.Sprintf("lineItems[%d].unitPrice", i), "NOTNULL", "must not be null")
+		}
+	}
+	return nil
+}
+
+// findSalesVoucher returns the sales voucher with id of any kind.
+func (s *Server) findSalesVoucher(id string) (object, salesVoucherKind, error) {
+	for _, kind := range salesVoucherKinds {
+		if voucher, ok := s.collection(kind.collection).items[id]; ok {
+			return voucher, kind, nil
+		}
+	}
+	return nil, salesVoucherKind{}, validationError("precedingSalesVoucherId", "INVALID", "sales voucher does not exist")
+}
+
+func appendRelatedVoucher(voucher, related object, kind salesVoucherKind) {
+	relatedVouchers, _ := voucher["relatedVouchers"].([]interface{})
+	voucher["relatedVouchers"] = append(relatedVouchers, object{
+		"id":            related["id"],
+		"voucherNumber": stringField(related, "voucherNumber"),
+		"voucherType":   string(kind.voucherType),
+	})
+}
+
+// calculateTotals sets the line item amounts, total price and tax amounts of a sales voucher
+// from the unit prices of its line items.
+func calculateTotals(voucher object) {
+	gross := stringField(voucher, "taxConditions", "taxType") == string(types.TaxTypeGross)
+	var totalNet, totalGross float64
+	byRate := make(map[float64][2]float64)
+
+	items, _ := voucher["lineItems"].([]interface{})
+	for _, item := range items {
+		li, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		price, ok := li["unitPrice"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rate := floatField(price, "taxRatePercentage")
+		net, grossAmount := floatField(price, "netAmount"), floatField(price, "grossAmount")
+		if gross {
+			net = round(grossAmount / (1 + rate/100))
+		} else {
+			grossAmount = round(net * (1 + rate/100))
+		}
+		price["netAmount"], price["grossAmount"] = net, grossAmount
+		if stringField(price, "currency") == "" {
+			price["currency"] = "EUR"
+		}
+
+		factor := floatField(li, "quantity") * (1 - floatField(li, "discountPercentage")/100)
+		lineNet, lineGross := round(net*factor), round(grossAmount*factor)
+		if gross {
+			li["lineItemAmount"] = lineGross
+		} else {
+			li["lineItemAmount"] = lineNet
+		}
+		totalNet += lineNet
+		totalGross += lineGross
+		sums := byRate[rate]
+		byRate[rate] = [2]float64{sums[0] + lineNet, sums[1] + lineGross}
+	}
+
+	rates := make([]float64, 0, len(byRate))
+	for rate := range byRate {
+		rates = append(rates, rate)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(rates)))
+	taxAmounts := make([]interface{}, 0, len(rates))
+	for _, rate := range rates {
+		sums := byRate[rate]
+		taxAmounts = append(taxAmounts, object{
+			"taxRatePercentage": rate,
+			"taxAmount":         round(sums[1] - sums[0]),
+			"netAmount":         round(sums[0]),
+		})
+	}
+
+	voucher["totalPrice"] = object{
+		"currency":         "EUR",
+		"totalNetAmount":   round(totalNet),
+		"totalGrossAmount": round(totalGross),
+		"totalTaxAmount":   round(totalGross - totalNet),
+	}
+	voucher["taxAmounts"] = taxAmounts
+}
+
+// renderDocument returns a handler rendering the document of a sales voucher. The document is
+// returned as PDF if requested by the Accept header or if pdf is set, and as a reference to the
+// document file otherwise.
+func (s *Server) renderDocument(kind salesVoucherKind, pdf bool) handlerFunc {
+	return func(r *http.Request) (int, interface{}, error) {
+		voucher, err := s.get(kind.collection, r.PathValue("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		if kind.voucherType != voucherTypeDunning && stringField(voucher, "voucherStatus") == string(types.VoucherStatusDraft) {
+			return 0, nil, errorf(http.StatusNotAcceptable, "Documents of draft vouchers can't be rendered.")
+		}
+
+		fileID := stringField(voucher, "files", "documentFileId")
+		if fileID == "" {
+			fileID = s.storeFile(file{contentType: "application/pdf", data: renderPDF(kind, voucher)})
+			voucher["files"] = object{"documentFileId": fileID}
+		}
+		if pdf || strings.Contains(r.Header.Get("Accept"), "application/pdf") {
+			return http.StatusOK, rawResponse{contentType: "application/pdf", data: s.files[fileID].data}, nil
+		}
+		return http.StatusOK, object{"documentFileId": fileID}, nil
+	}
+}
+
+// renderPDF returns a minimal PDF document identifying the voucher.
+func renderPDF(kind salesVoucherKind, voucher object) []byte {
+	return []byte(fmt.Sprintf("%%PDF-1.4\n%% %s %s %s\n%%%%EOF\n",
+		kind.voucherType, stringField(voucher, "voucherNumber"), stringField(voucher, "id")))
+}
+
+func (s *Server) createVoucher(r *http.Request) (int, interface{}, error) {
+	voucher, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.validateVoucher(voucher); err != nil {
+		return 0, nil, err
+	}
+	voucher["voucherStatus"] = string(types.VoucherStatusOpen)
+	voucher["files"] = []interface{}{}
+	voucher["archived"] = false
+	return http.StatusCreated, s.insert("vouchers", voucher), nil
+}
+
+func (s *Server) updateVoucher(r *http.Request) (int, interface{}, error) {
+	voucher, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.validateVoucher(voucher); err != nil {
+		return 0, nil, err
+	}
+	existing, err := s.get("vouchers", r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	// The type of a voucher can't be changed.
+	voucher["type"] = existing["type"]
+	result, err := s.update("vouchers", r.PathValue("id"), voucher)
+	return http.StatusOK, result, err
+}
+
+func (s *Server) validateVoucher(voucher object) error {
+	if _, ok := voucher["type"]; ok && !slices.Contains(bookkeepingVoucherTypes, types.VoucherType(stringField(voucher, "type"))) {
+		return validationError("type", "INVALID", "must be one of salesinvoice, salescreditnote, purchaseinvoice, purchasecreditnote")
+	}
+	if err := require(voucher, "voucherDate", "taxType", "voucherItems"); err != nil {
+		return err
+	}
+	if contactID := stringField(voucher, "contactId"); contactID != "" {
+		if _, ok := s.collection("contacts").items[contactID]; !ok {
+			return validationError("contactId", "INVALID", "contact does not exist")
+		}
+	}
+	items, _ := voucher["voucherItems"].([]interface{})
+	if len(items) == 0 {
+		return validationError("voucherItems", "NOTEMPTY", "must not be empty")
+	}
+	for i, item := range items {
+		li, _ := item.(map[string]interface{})
+		categoryID := stringField(li, "categoryId")
+		if !slices.ContainsFunc(s.PostingCategories, func(c types.PostingCategory) bool { return c.ID == categoryID }) {
+			return validationError(fmt.Sprintf("voucherItems[%d].categoryId", i), "INVALID", "posting category does not exist")
+		}
+	}
+	return nil
+}
+
+func (s *Server) listVouchers(r *http.Request) (int, interface{}, error) {
+	query := r.URL.Query()
+	vouchers := s.list("vouchers", func(v object) bool {
+		return (query.Get("voucherNumber") == "" || stringField(v, "voucherNumber") == query.Get("voucherNumber")) &&
+			(query.Get("voucherStatus") == "" || stringField(v, "voucherStatus") == query.Get("voucherStatus")) &&
+			(query.Get("contactId") == "" || stringField(v, "contactId") == query.Get("contactId"))
+	})
+	page, err := paginate(r, vouchers)
+	return http.StatusOK, page, err
+}
+
+func (s *Server) uploadVoucherFile(r *http.Request) (int, interface{}, error) {
+	voucher, err := s.get("vouchers", r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	_, f, err := readMultipart(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	id := s.storeFile(f)
+	files, _ := voucher["files"].([]interface{})
+	voucher["files"] = append(files, object{"id": id})
+	return http.StatusAccepted, object{"id": id}, nil
+}
+
+// listVoucherList lists sales vouchers and bookkeeping vouchers in the order they were created.
+func (s *Server) listVoucherList(r *http.Request) (int, interface{}, error) {
+	var items []object
+	for _, kind := range salesVoucherKinds {
+		for _, v := range s.list(kind.collection, nil) {
+			items = append(items, object{
+				"id":            v["id"],
+				"voucherType":   string(kind.voucherType),
+				"voucherStatus": v["voucherStatus"],
+				"voucherNumber": stringField(v, "voucherNumber"),
+				"voucherDate":   stringField(v, "voucherDate"),
+				"createdDate":   v["createdDate"],
+				"updatedDate":   v["updatedDate"],
+				"dueDate":       stringField(v, "dueDate"),
+				"contactId":     stringField(v, "address", "contactId"),
+				"contactName":   stringField(v, "address", "name"),
+				"totalAmount":   floatField(v, "totalPrice", "totalGrossAmount"),
+				"openAmount":    openAmount(v, floatField(v, "totalPrice", "totalGrossAmount")),
+				"currency":      "EUR",
+				"archived":      boolField(v, "archived"),
+			})
+		}
+	}
+	for _, v := range s.list("vouchers", nil) {
+		var name string
+		if contact, ok := s.collection("contacts").items[stringField(v, "contactId")]; ok {
+			name = contactName(contact)
+		}
+		items = append(items, object{
+			"id":            v["id"],
+			"voucherType":   v["type"],
+			"voucherStatus": v["voucherStatus"],
+			"voucherNumber": stringField(v, "voucherNumber"),
+			"voucherDate":   stringField(v, "voucherDate"),
+			"createdDate":   v["createdDate"],
+			"updatedDate":   v["updatedDate"],
+			"dueDate":       stringField(v, "dueDate"),
+			"contactId":     stringField(v, "contactId"),
+			"contactName":   name,
+			"totalAmount":   floatField(v, "totalGrossAmount"),
+			"openAmount":    openAmount(v, floatField(v, "totalGrossAmount")),
+			"currency":      "EUR",
+			"archived":      boolField(v, "archived"),
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return stringField(items[i], "createdDate") < stringField(items[j], "createdDate")
+	})
+
+	query := r.URL.Query()
+	voucherTypes := listParam(query.Get("voucherType"))
+	voucherStatuses := listParam(query.Get("voucherStatus"))
+	inRange := func(value, from, to string) bool {
+		value = datePart(value)
+		return (from == "" || value >= from) && (to == "" || value <= to)
+	}
+	items = slices.DeleteFunc(items, func(item object) bool {
+		return !(voucherTypes == nil || slices.Contains(voucherTypes, stringField(item, "voucherType"))) ||
+			!(voucherStatuses == nil || slices.Contains(voucherStatuses, stringField(item, "voucherStatus"))) ||
+			!(query.Get("archived") == "" || fmt.Sprint(boolField(item, "archived")) == query.Get("archived")) ||
+			!(query.Get("contactId") == "" || stringField(item, "contactId") == query.Get("contactId")) ||
+			!inRange(stringField(item, "voucherDate"), query.Get("voucherDateFrom"), query.Get("voucherDateTo")) ||
+			!inRange(stringField(item, "createdDate"), query.Get("createdDateFrom"), query.Get("createdDateTo")) ||
+			!inRange(stringField(item, "updatedDate"), query.Get("updatedDateFrom"), query.Get("updatedDateTo"))
+	})
+	keys := make(map[string]func(object) interface{})
+	for _, name := range []string{"voucherDate", "voucherNumber", "createdDate", "updatedDate", "dueDate", "contactName", "totalAmount", "openAmount"} {
+		keys[name] = func(item object) interface{} { return item[name] }
+	}
+	if err := sortItems(r, items, keys); err != nil {
+		return 0, nil, err
+	}
+
+	page, err := paginate(r, items)
+	return http.StatusOK, page, err
+}
+
+// listParam splits a comma-separated query parameter. It returns nil for an empty value or
+// "any", which match all values.
+func listParam(value string) []string {
+	if value == "" || value == "any" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// openAmount returns the amount still to be paid for a voucher with the given total.
+func openAmount(voucher object, total float64) float64 {
+	if stringField(voucher, "voucherStatus") != string(types.VoucherStatusOpen) {
+		return 0
+	}
+	return total
+}
+
+func (s *Server) getPayment(r *http.Request) (int, interface{}, error) {
+	id := r.PathValue("id")
+	voucher, kind, err := s.findSalesVoucher(id)
+	var voucherType string
+	var total float64
+	if err == nil {
+		voucherType = string(kind.voucherType)
+		total = floatField(voucher, "totalPrice", "totalGrossAmount")
+	} else if voucher, err = s.get("vouchers", id); err == nil {
+		voucherType = stringField(voucher, "type")
+		total = floatField(voucher, "totalGrossAmount")
+	} else {
+		return 0, nil, notFound()
+	}
+	if stringField(voucher, "voucherStatus") == string(types.VoucherStatusDraft) {
+		return 0, nil, errorf(http.StatusNotAcceptable, "Payments of draft vouchers are not available.")
+	}
+
+	status := "openRevenue"
+	switch types.VoucherType(voucherType) {
+	case types.VoucherTypeCreditNote, types.VoucherTypeSalesCreditNote, types.VoucherTypePurchaseInvoice:
+		status = "openExpense"
+	}
+	open := openAmount(voucher, total)
+	if open == 0 {
+		status = "balanced"
+	}
+	return http.StatusOK, types.Payment{
+		OpenAmount:    open,
+		Currency:      "EUR",
+		PaymentStatus: status,
+		VoucherType:   voucherType,
+		VoucherID:     id,
+		VoucherNumber: stringField(voucher, "voucherNumber"),
+		VoucherDate:   stringField(voucher, "voucherDate"),
+	}, nil
+}
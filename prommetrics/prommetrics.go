@@ -0,0 +1,98 @@
+// Package prommetrics provides a Prometheus implementation of lexware.Metrics.
+//
+//	metrics, err := prommetrics.New(prometheus.DefaultRegisterer)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	client, err := lexware.NewClientWithConfig(lexware.Config{
+//	    APIKey:  "your-api-key",
+//	    Metrics: metrics,
+//	})
+//
+// The following metrics are registered, all labeled by the operation, e.g. "Invoices.Create":
+//
+//   - lexware_requests_total: attempts sent to the API, labeled by status code
+//   - lexware_request_duration_seconds: latency of the attempts
+//   - lexware_retries_total: retried attempts, labeled by the status code that caused the retry
+//   - lexware_rate_limiter_wait_seconds: time the attempts were blocked by the rate limiter
+package prommetrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+)
+
+// Metrics implements lexware.Metrics with Prometheus collectors.
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	retries       *prometheus.CounterVec
+	rateLimitWait *prometheus.HistogramVec
+}
+
+var _ lexware.Metrics = (*Metrics)(nil)
+
+// New creates the collectors and registers them with reg. If one of them can't be registered,
+// e.g. because New was called with reg before, none of them stays registered.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lexware",
+			Name:      "requests_total",
+			Help:      "Number of requests sent to the Lexware API, including retries.",
+		}, []string{"operation", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "lexware",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests sent to the Lexware API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lexware",
+			Name:      "retries_total",
+			Help:      "Number of retried requests to the Lexware API.",
+		}, []string{"operation", "code"}),
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "lexware",
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time requests were blocked by the client's rate limiter.",
+			Buckets:   []float64{0.001, 0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"operation"}),
+	}
+
+	collectors := []prometheus.Collector{m.requests, m.duration, m.retries, m.rateLimitWait}
+	for i, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			// Leave reg as it was, so New may be called again, e.g. with another registry.
+			for _, registered := range collectors[:i] {
+				reg.Unregister(registered)
+			}
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Metrics) ObserveRequest(operation string, statusCode int, duration time.Duration) {
+	m.requests.WithLabelValues(operation, code(statusCode)).Inc()
+	m.duration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveRetry(operation string, statusCode int) {
+	m.retries.WithLabelValues(operation, code(statusCode)).Inc()
+}
+
+func (m *Metrics) ObserveRateLimitWait(operation string, wait time.Duration) {
+	m.rateLimitWait.WithLabelValues(operation).Observe(wait.Seconds())
+}
+
+// code returns the status code label, "error" for requests that failed without a response.
+func code(statusCode int) string {
+	if statusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(statusCode)
+}
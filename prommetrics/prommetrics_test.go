@@ -0,0 +1,160 @@
+package prommetrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+)
+
+// series returns the metrics of reg by family name, each keyed by its label values.
+func series(t *testing.T, reg *prometheus.Registry) map[string]map[string]*dto.Metric {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]map[string]*dto.Metric)
+	for _, family := range families {
+		metrics := make(map[string]*dto.Metric)
+		for _, m := range family.GetMetric() {
+			key := ""
+			for _, label := range m.GetLabel() {
+				key += label.GetName() + "=" + label.GetValue() + ","
+			}
+			metrics[key] = m
+		}
+		result[family.GetName()] = metrics
+	}
+	return result
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.ObserveRequest("Invoices.Get", 200, 100*time.Millisecond)
+	m.ObserveRequest("Invoices.Get", 200, 300*time.Millisecond)
+	m.ObserveRequest("Invoices.Get", 0, time.Second)
+	m.ObserveRetry("Invoices.Get", 503)
+	m.ObserveRetry("Invoices.Get", 0)
+	m.ObserveRateLimitWait("Contacts.List", 2*time.Second)
+
+	got := series(t, reg)
+	counters := []struct {
+		family string
+		labels string
+		want   float64
+	}{
+		{"lexware_requests_total", "code=200,operation=Invoices.Get,", 2},
+		{"lexware_requests_total", "code=error,operation=Invoices.Get,", 1},
+		{"lexware_retries_total", "code=503,operation=Invoices.Get,", 1},
+		{"lexware_retries_total", "code=error,operation=Invoices.Get,", 1},
+	}
+	for _, c := range counters {
+		m, ok := got[c.family][c.labels]
+		if !ok {
+			t.Errorf("%s{%s} missing", c.family, c.labels)
+			continue
+		}
+		if v := m.GetCounter().GetValue(); v != c.want {
+			t.Errorf("%s{%s} = %v, want %v", c.family, c.labels, v, c.want)
+		}
+	}
+
+	histograms := []struct {
+		family string
+		labels string
+		count  uint64
+		sum    float64
+	}{
+		{"lexware_request_duration_seconds", "operation=Invoices.Get,", 3, 1.4},
+		{"lexware_rate_limiter_wait_seconds", "operation=Contacts.List,", 1, 2},
+	}
+	for _, h := range histograms {
+		m, ok := got[h.family][h.labels]
+		if !ok {
+			t.Errorf("%s{%s} missing", h.family, h.labels)
+			continue
+		}
+		hist := m.GetHistogram()
+		if hist.GetSampleCount() != h.count || hist.GetSampleSum() < h.sum-1e-9 || hist.GetSampleSum() > h.sum+1e-9 {
+			t.Errorf("%s{%s} = %d samples summing to %v, want %d summing to %v", h.family, h.labels, hist.GetSampleCount(), hist.GetSampleSum(), h.count, h.sum)
+		}
+	}
+}
+
+func TestMetricsClient(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"8a4c4b3e-1234-4abc-9def-0123456789ab"}`))
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	m, err := New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := lexware.NewClientWithConfig(lexware.Config{
+		APIKey:    "test",
+		BaseURL:   server.URL,
+		RateLimit: -1,
+		Metrics:   m,
+		Retry:     lexware.RetryPolicy{InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Contacts().Get(context.Background(), "8a4c4b3e-1234-4abc-9def-0123456789ab"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := series(t, reg)
+	for labels, want := range map[string]float64{
+		"code=503,operation=Contacts.Get,": 1,
+		"code=200,operation=Contacts.Get,": 1,
+	} {
+		if v := got["lexware_requests_total"][labels].GetCounter().GetValue(); v != want {
+			t.Errorf("lexware_requests_total{%s} = %v, want %v", labels, v, want)
+		}
+	}
+	if v := got["lexware_retries_total"]["code=503,operation=Contacts.Get,"].GetCounter().GetValue(); v != 1 {
+		t.Errorf("lexware_retries_total{code=503} = %v, want 1", v)
+	}
+}
+
+func TestNewRegistersAllOrNothing(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	// Takes the place of the third collector.
+	reg.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lexware_retries_total",
+		Help: "Number of retried requests to the Lexware API.",
+	}, []string{"operation", "code"}))
+
+	if _, err := New(reg); err == nil {
+		t.Fatal("New() succeeded despite a conflicting collector")
+	}
+	// The collectors registered before the failure were removed again.
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lexware_requests_total",
+		Help: "Number of requests sent to the Lexware API, including retries.",
+	}, []string{"operation", "code"})
+	if err := reg.Register(requests); err != nil {
+		t.Errorf("lexware_requests_total still registered after New() failed: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+// Package recorder records the HTTP interactions of a lexware.Client in cassette files and
+// replays them, so code using the client can be tested without hand-written handlers or
+// network access.
+//
+// Record a cassette once against the real API:
+//
+//	rec, err := recorder.New("testdata/create_invoice.json", recorder.Options{Mode: recorder.ModeRecord})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rec.Stop() // Writes the cassette
+//	client, err := lexware.NewClientWithConfig(lexware.Config{
+//	    APIKey:     os.Getenv("LEXWARE_API_KEY"),
+//	    HTTPClient: rec.Client(),
+//	})
+//
+// and replay it in tests:
+//
+//	rec, err := recorder.New("testdata/create_invoice.json", recorder.Options{})
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer func() {
+//	    if err := rec.Stop(); err != nil {
+//	        t.Error(err)
+//	    }
+//	}()
+//	client, err := lexware.NewClientWithConfig(lexware.Config{
+//	    APIKey:     "test",
+//	    HTTPClient: rec.Client(),
+//	    RateLimit:  -1,
+//	})
+//
+// Cassettes never contain the Authorization header. Personal data is redacted from query
+// parameters and JSON bodies like in logged requests, see lexware.RedactJSON.
+//
+// Requests are matched on method, path, query and body. Query parameters are compared
+// regardless of their order, JSON bodies regardless of formatting and key order, and multipart
+// bodies regardless of their boundary. Each recorded interaction is replayed once, in recorded
+// order, so repeated identical requests receive their responses in sequence. Requests matching
+// no interaction fail with StatusNoInteraction and make Stop return ErrNoInteraction.
+package recorder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+)
+
+// ErrNoInteraction is returned by Stop when replayed requests matched no unused interaction of
+// the cassette.
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+// StatusNoInteraction is the status code of the response to a replayed request that matches no
+// unused interaction. The client reports it as *lexware.APIError without retrying the request or
+// counting it as a failure of the API.
+const StatusNoInteraction = http.StatusMisdirectedRequest
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette without sending them.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the API and records them in the cassette.
+	ModeRecord
+)
+
+// Options holds configuration options for a Recorder.
+type Options struct {
+	// Mode selects recording or replaying. Defaults to ModeReplay.
+	Mode Mode
+	// Transport sends requests in ModeRecord. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// RedactFields lists the JSON fields and query parameters to redact. Defaults to
+	// lexware.DefaultRedactFields.
+	RedactFields []string
+}
+
+// Recorder is an http.RoundTripper recording or replaying a cassette.
+type Recorder struct {
+	path         string
+	mode         Mode
+	transport    http.RoundTripper
+	redactFields []string
+
+	mu           sync.Mutex
+	interactions []*interaction
+	unmatched    []string
+}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+	used     bool
+}
+
+type recordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	body
+}
+
+type recordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	body
+}
+
+// body holds a body as text if possible, or base64-encoded otherwise.
+type body struct {
+	Body       string `json:"body,omitempty"`
+	BodyBase64 string `json:"bodyBase64,omitempty"`
+}
+
+func newBody(b []byte) body {
+	if utf8.Valid(b) {
+		return body{Body: string(b)}
+	}
+	return body{BodyBase64: base64.StdEncoding.EncodeToString(b)}
+}
+
+func (b body) bytes() ([]byte, error) {
+	if b.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(b.BodyBase64)
+	}
+	return []byte(b.Body), nil
+}
+
+type cassette struct {
+	Interactions []*interaction `json:"interactions"`
+}
+
+// New creates a Recorder for the cassette at path. In ModeReplay, the cassette must exist.
+func New(path string, opts Options) (*Recorder, error) {
+	r := &Recorder{
+		path:         path,
+		mode:         opts.Mode,
+		transport:    opts.Transport,
+		redactFields: opts.RedactFields,
+	}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		var c cassette
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+		}
+		r.interactions = c.Interactions
+	}
+	return r, nil
+}
+
+// Client returns an HTTP client using the Recorder, to be passed as lexware.Config.HTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays a request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	recorded := recordedRequest{
+		Method: req.Method,
+		URL:    lexware.RedactURL(req.URL, r.redactFields),
+		body:   newBody(maskBoundary(req.Header, r.redact(req.Header, reqBody))),
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, reqBody, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, reqBody []byte, recorded recordedRequest) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	// The recorded body may differ in length after redaction.
+	header.Del("Content-Length")
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &interaction{
+		Request: recorded,
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			body:       newBody(r.redact(resp.Header, respBody)),
+		},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded recordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.interactions {
+		if i.used || i.Request != recorded {
+			continue
+		}
+		respBody, err := i.Response.bytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+		}
+		i.used = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+			StatusCode:    i.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        i.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+	desc := recorded.Method + " " + recorded.URL
+	r.unmatched = append(r.unmatched, desc)
+	// An error would be taken for a network error and the request retried.
+	respBody, _ := json.Marshal(map[string]interface{}{
+		"status":  StatusNoInteraction,
+		"error":   http.StatusText(StatusNoInteraction),
+		"message": fmt.Sprintf("%v: %s in cassette %s", ErrNoInteraction, desc, r.path),
+	})
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", StatusNoInteraction, http.StatusText(StatusNoInteraction)),
+		StatusCode:    StatusNoInteraction,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// Stop finishes the Recorder. In ModeRecord, it writes the cassette. In ModeReplay, it returns
+// an error listing the requests that matched no interaction, if any.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mode == ModeReplay {
+		if len(r.unmatched) > 0 {
+			return fmt.Errorf("%w in cassette %s: %s", ErrNoInteraction, r.path, strings.Join(r.unmatched, ", "))
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cassette{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// redact redacts JSON bodies. Other bodies, e.g. uploads and document downloads, are kept as is.
+func (r *Recorder) redact(header http.Header, b []byte) []byte {
+	if len(b) == 0 || !strings.Contains(header.Get("Content-Type"), "json") {
+		return b
+	}
+	return lexware.RedactJSON(b, r.redactFields)
+}
+
+// boundaryMask replaces the boundary of recorded multipart bodies.
+const boundaryMask = "recorder-boundary"
+
+// maskBoundary replaces the boundary of a multipart body, which is random for every request, so
+// uploads can be matched.
+func maskBoundary(header http.Header, b []byte) []byte {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return b
+	}
+	return bytes.ReplaceAll(b, []byte(params["boundary"]), []byte(boundaryMask))
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
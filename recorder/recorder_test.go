@@ -0,0 +1,164 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rasche-thalhofer/lexware-go/lexware"
+	"github.com/rasche-thalhofer/lexware-go/lexwaretest"
+	"github.com/rasche-thalhofer/lexware-go/types"
+)
+
+const email = "jane.doe@example.com"
+
+// session runs the same calls against a client when recording and when replaying.
+func session(t *testing.T, client *lexware.Client) (contact *types.Contact, uploads []string) {
+	t.Helper()
+	ctx := context.Background()
+	result, err := client.Contacts().Create(ctx, &types.ContactCreateRequest{
+		Roles:          &types.ContactRoles{Customer: &types.CustomerRole{}},
+		Company:        &types.Company{Name: "Doe Ltd"},
+		EmailAddresses: &types.EmailAddresses{Business: []string{email}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if contact, err = client.Contacts().Get(ctx, result.ID); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	page, err := client.Contacts().List(ctx, nil, &types.ContactFilterOptions{Email: email})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.Content) != 1 {
+		t.Fatalf("List() returned %d contacts, want 1", len(page.Content))
+	}
+	// Every upload is sent with a new random multipart boundary.
+	for range 2 {
+		upload, err := client.Files().Upload(ctx, "receipt.pdf", strings.NewReader("%PDF-1.4 receipt"), types.FileUploadType("voucher"))
+		if err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		uploads = append(uploads, upload.ID)
+	}
+	return contact, uploads
+}
+
+func TestRecordReplay(t *testing.T) {
+	server := lexwaretest.NewServer()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := New(path, Options{Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := server.Config()
+	config.HTTPClient = rec.Client()
+	client, err := lexware.NewClientWithConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordedContact, recordedUploads := session(t, client)
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := string(data)
+	for _, secret := range []string{email, lexwaretest.APIKey} {
+		if strings.Contains(cassette, secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	if !strings.Contains(cassette, "email=%5BREDACTED%5D") {
+		t.Error("cassette doesn't contain the redacted email query parameter")
+	}
+	if got := strings.Count(cassette, boundaryMask); got < 4 {
+		t.Errorf("cassette contains the masked boundary %d times, want it in both uploads", got)
+	}
+
+	// Replay without the server.
+	server.Close()
+	rec, err = New(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.HTTPClient = rec.Client()
+	if client, err = lexware.NewClientWithConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	contact, uploads := session(t, client)
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if contact.ID != recordedContact.ID || contact.Company.Name != "Doe Ltd" {
+		t.Errorf("replayed contact %s %q, want %s %q", contact.ID, contact.Company.Name, recordedContact.ID, "Doe Ltd")
+	}
+	if got := contact.EmailAddresses.Business; len(got) != 1 || got[0] == email {
+		t.Errorf("replayed email addresses = %v, want them redacted", got)
+	}
+	if strings.Join(uploads, ",") != strings.Join(recordedUploads, ",") {
+		t.Errorf("replayed uploads %v, want %v", uploads, recordedUploads)
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := os.WriteFile(path, []byte(`{"interactions":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := New(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attempts int
+	client, err := lexware.NewClientWithConfig(lexware.Config{
+		APIKey:     "test",
+		HTTPClient: rec.Client(),
+		RateLimit:  -1,
+		Middleware: []lexware.Middleware{
+			lexware.InterceptRequest(func(*lexware.Request) error {
+				attempts++
+				return nil
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Contacts().Get(context.Background(), "8a4c4b3e-1234-4abc-9def-0123456789ab")
+	var apiErr *lexware.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != StatusNoInteraction {
+		t.Fatalf("Get() error = %v, want an APIError with status %d", err, StatusNoInteraction)
+	}
+	if !strings.Contains(apiErr.Message, ErrNoInteraction.Error()) {
+		t.Errorf("error message = %q, want it to mention %q", apiErr.Message, ErrNoInteraction)
+	}
+	if attempts != 1 {
+		t.Errorf("request sent %d times, want 1", attempts)
+	}
+
+	err = rec.Stop()
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("Stop() error = %v, want ErrNoInteraction", err)
+	}
+	if got := strings.Count(err.Error(), "GET /v1/contacts/"); got != 1 {
+		t.Errorf("Stop() lists the request %d times, want 1: %v", got, err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), Options{}); err == nil {
+		t.Error("New() of a missing cassette succeeded")
+	}
+}
@@ -184,6 +184,7 @@ type VoucherListItem struct {
 	VoucherStatus VoucherStatus `json:"voucherStatus,omitempty"`
 	VoucherNumber string        `json:"voucherNumber,omitempty"`
 	VoucherDate   string        `json:"voucherDate,omitempty"`
+	CreatedDate   string        `json:"createdDate,omitempty"`
 	UpdatedDate   string        `json:"updatedDate,omitempty"`
 	DueDate       string        `json:"dueDate,omitempty"`
 	ContactID     string        `json:"contactId,omitempty"`
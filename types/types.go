@@ -179,4 +179,32 @@ type ActionResult struct {
 type ListOptions struct {
 	Page int
 	Size int
+	// Sort orders the results by a field. The voucherlist can be sorted by all fields except
+	// SortByName, contacts only by SortByName. Other endpoints don't support sorting.
+	Sort SortField
+	// Direction is the order of sorted results, SortAsc if empty.
+	Direction SortDirection
 }
+
+// SortField is a field list results can be sorted by.
+type SortField string
+
+const (
+	SortByVoucherDate   SortField = "voucherDate"
+	SortByVoucherNumber SortField = "voucherNumber"
+	SortByCreatedDate   SortField = "createdDate"
+	SortByUpdatedDate   SortField = "updatedDate"
+	SortByDueDate       SortField = "dueDate"
+	SortByContactName   SortField = "contactName"
+	SortByTotalAmount   SortField = "totalAmount"
+	SortByOpenAmount    SortField = "openAmount"
+	SortByName          SortField = "name"
+)
+
+// SortDirection is the order of sorted list results.
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "ASC"
+	SortDesc SortDirection = "DESC"
+)